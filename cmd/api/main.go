@@ -6,13 +6,16 @@ import (
 	"errors"
 	"log"
 	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
 	"ticket-api/internal/config"
 	"ticket-api/internal/env"
 	"ticket-api/internal/errx"
 	"ticket-api/internal/handler"
 	"ticket-api/internal/repository"
 	"ticket-api/internal/services"
+	"ticket-api/internal/services/storage"
 	"time"
 
 	_ "github.com/joho/godotenv/autoload"
@@ -58,12 +61,21 @@ func main() {
 
 	// MinIO
 	var minioClient *minio.Client = nil
-	if config.Get().Minio.Enable {
+	if driver := config.Get().Storage.Driver; driver == storage.DriverMinio || driver == "" {
 		minioClient, err = ConnectMinio()
 		fatalIfErr(err)
 	}
 
-	services := services.NewAppService(dbRedis, minioClient)
+	// cancelled on shutdown, background services stop with it
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	services, err := services.NewAppService(dbRedis, minioClient)
+	fatalIfErr(err)
+	if config.Get().Storage.TempCleanup.Enable {
+		fatalIfErr(services.Janitor.Start(ctx))
+	}
+
 	repos := repository.NewRepositories(dbSQL, dbMongo, services)
 	handlers := handler.NewAppHandlers(repos, services)
 
@@ -78,9 +90,11 @@ func main() {
 		handlers: handlers,
 	}
 
-	if err := app.serve(); err != nil {
-		log.Fatal(err)
-	}
+	err = app.serve(ctx)
+	// release the janitor lease before exiting, also when the server failed
+	stop()
+	services.Janitor.Wait()
+	fatalIfErr(err)
 }
 
 // fatalIfErr logs and exits if err is not nil
@@ -52,6 +52,14 @@ func (app *application) routes() http.Handler {
 			captchaGroup.POST(routes.APIRoutes.Auth.LoginWithNoAuth.Path, app.handlers.Auth.LoginWithNoAuth)
 		}
 
+		// Event streams are long-lived, so they skip the body limit and get their own rate limit
+		streamGroup := v1.Group("")
+		streamGroup.Use(middleware.CaptchaMiddleware(app.services.Token))
+		streamGroup.Use(middleware.RateLimitMiddleware(app.redis, 10))
+		{
+			streamGroup.GET(routes.APIRoutes.Tickets.Stream.Path, app.handlers.TicketStream.StreamTicketHandler)
+		}
+
 		LoginGroup := v1.Group("")
 		LoginGroup.Use(middleware.RateLimitMiddleware(app.redis, 10))
 		LoginGroup.POST(routes.APIRoutes.Auth.Login.Path, app.handlers.Auth.LoginWithPassword)
@@ -66,6 +74,39 @@ func (app *application) routes() http.Handler {
 			authGroup.POST(routes.APIRoutes.Users.GetUserByID.Path, app.handlers.User.GetUserByID)
 			authGroup.POST(routes.APIRoutes.Users.GetUserByUsername.Path, app.handlers.User.GetUserByUsername)
 			authGroup.POST(routes.APIRoutes.Tickets.GetTicketByID.Path, app.handlers.Ticket.GetTicketByIDHandler)
+			authGroup.GET(routes.APIRoutes.Tickets.GetTicketMessages.Path, app.handlers.Chat.GetTicketMessagesHandler)
+			authGroup.POST(routes.APIRoutes.Tickets.AddTicketTypeField.Path, app.handlers.Ticket.AddTicketTypeFieldHandler)
+			authGroup.POST(routes.APIRoutes.Tickets.DeleteTicketTypeField.Path, app.handlers.Ticket.DeleteTicketTypeFieldHandler)
+			authGroup.POST(routes.APIRoutes.Tickets.CreateInternalNote.Path, app.handlers.Chat.CreateInternalNoteHandler)
+			authGroup.POST(routes.APIRoutes.Tickets.EditChat.Path, app.handlers.Chat.EditChatHandler)
+			authGroup.POST(routes.APIRoutes.Tickets.DeleteChat.Path, app.handlers.Chat.DeleteChatHandler)
+			authGroup.GET(routes.APIRoutes.Tickets.LiveChat.Path, app.handlers.Chat.LiveChatHandler)
+			authGroup.POST(routes.APIRoutes.Tickets.MarkTicketRead.Path, app.handlers.Chat.MarkTicketReadHandler)
+			authGroup.POST(routes.APIRoutes.Tickets.AssignTicket.Path, app.handlers.Ticket.AssignTicketHandler)
+			authGroup.POST(routes.APIRoutes.Tickets.UnassignTicket.Path, app.handlers.Ticket.UnassignTicketHandler)
+			authGroup.POST(routes.APIRoutes.Tickets.ChangeTicketStatus.Path, app.handlers.Ticket.ChangeTicketStatusHandler)
+			authGroup.POST(routes.APIRoutes.Tickets.SetTicketPriority.Path, app.handlers.Ticket.SetTicketPriorityHandler)
+			authGroup.POST(routes.APIRoutes.Tickets.AddTicketTags.Path, app.handlers.Ticket.AddTicketTagsHandler)
+			authGroup.POST(routes.APIRoutes.Tickets.RemoveTicketTags.Path, app.handlers.Ticket.RemoveTicketTagsHandler)
+			authGroup.POST(routes.APIRoutes.Tickets.BulkUpdate.Path, app.handlers.Ticket.BulkUpdateTicketsHandler)
+			authGroup.GET(routes.APIRoutes.Tickets.GetTicketHistory.Path, app.handlers.Ticket.GetTicketHistoryHandler)
+
+			authGroup.POST(routes.APIRoutes.TicketViews.CreateView.Path, app.handlers.TicketView.CreateViewHandler)
+			authGroup.POST(routes.APIRoutes.TicketViews.UpdateView.Path, app.handlers.TicketView.UpdateViewHandler)
+			authGroup.POST(routes.APIRoutes.TicketViews.DeleteView.Path, app.handlers.TicketView.DeleteViewHandler)
+			authGroup.GET(routes.APIRoutes.TicketViews.GetViews.Path, app.handlers.TicketView.GetViewsHandler)
+			authGroup.POST(routes.APIRoutes.TicketViews.RunView.Path, app.handlers.TicketView.RunViewHandler)
+
+			authGroup.POST(routes.APIRoutes.Tags.CreateTag.Path, app.handlers.Tag.CreateTagHandler)
+			authGroup.POST(routes.APIRoutes.Tags.UpdateTag.Path, app.handlers.Tag.UpdateTagHandler)
+			authGroup.GET(routes.APIRoutes.Tags.GetAllActiveTags.Path, app.handlers.Tag.GetAllActiveTagsHandler)
+			authGroup.POST(routes.APIRoutes.Tags.GetTagUsage.Path, app.handlers.Tag.GetTagUsageHandler)
+
+			authGroup.POST(routes.APIRoutes.CannedResponses.CreateCannedResponse.Path, app.handlers.CannedResponse.CreateCannedResponseHandler)
+			authGroup.POST(routes.APIRoutes.CannedResponses.UpdateCannedResponse.Path, app.handlers.CannedResponse.UpdateCannedResponseHandler)
+			authGroup.POST(routes.APIRoutes.CannedResponses.DeleteCannedResponse.Path, app.handlers.CannedResponse.DeleteCannedResponseHandler)
+			authGroup.GET(routes.APIRoutes.CannedResponses.GetCannedResponses.Path, app.handlers.CannedResponse.GetCannedResponsesHandler)
+			authGroup.POST(routes.APIRoutes.CannedResponses.GetCannedResponseUsage.Path, app.handlers.CannedResponse.GetCannedResponseUsageHandler)
 		}
 
 		publicGroup := v1.Group("")
@@ -80,6 +121,7 @@ func (app *application) routes() http.Handler {
 
 			publicGroup.GET(routes.APIRoutes.Tickets.GetAllActiveTicketTypes.Path, app.handlers.Ticket.GetAllActiveTicketTypesHandler)
 			publicGroup.GET(routes.APIRoutes.Tickets.GetAllActiveTicketStatuses.Path, app.handlers.Ticket.GetAllActiveTicketStatusesHandler)
+			publicGroup.GET(routes.APIRoutes.Tickets.GetTicketTypeFields.Path, app.handlers.Ticket.GetTicketTypeFieldsHandler)
 			publicGroup.GET(routes.APIRoutes.Departments.GetAllActiveDepartments.Path, app.handlers.Department.GetAllActiveDepartmentsHandler)
 
 		}
@@ -87,8 +129,10 @@ func (app *application) routes() http.Handler {
 		fileGroup := v1.Group("")
 		fileGroup.Use(middleware.RateLimitMiddleware(app.redis, 10))
 		{
-			fileGroup.POST(routes.APIRoutes.Files.UploadTicketFile.Path, app.handlers.File.UploadTicketFileHandler)
+			// uploads are bound to the user or captcha token of the uploader
+			fileGroup.POST(routes.APIRoutes.Files.UploadTicketFile.Path, middleware.CaptchaMiddleware(app.services.Token), app.handlers.File.UploadTicketFileHandler)
 			fileGroup.POST(routes.APIRoutes.Files.GetDownloadLinkTicketFile.Path, app.handlers.File.GetDownloadLinkTicketFileHandler)
+			fileGroup.GET(routes.APIRoutes.Files.DownloadTicketFile.Path, app.handlers.File.DownloadTicketFileHandler)
 		}
 
 		_APIKeyGroup := v1.Group("")
@@ -0,0 +1,65 @@
+// Command cleanuptemp deletes the temp uploads that were never attached to a ticket, like the
+// janitor of the API does on schedule. It is safe to run while the API is up.
+//
+//	go run ./cmd/cleanuptemp [-max-age 24h]
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+	"ticket-api/internal/config"
+	"ticket-api/internal/env"
+	"ticket-api/internal/errx"
+	"ticket-api/internal/services/storage"
+	"time"
+
+	_ "github.com/joho/godotenv/autoload"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+func main() {
+	config.Load("config.yaml")
+	errx.NewRegistry(nil)
+
+	defaultMaxAge := time.Duration(config.Get().Storage.TempCleanup.MaxAgeMinutes) * time.Minute
+	maxAge := flag.Duration("max-age", defaultMaxAge, "delete temp uploads older than this")
+	flag.Parse()
+
+	var minioClient *minio.Client
+	if driver := config.Get().Storage.Driver; driver == storage.DriverMinio || driver == "" {
+		var err error
+		minioClient, err = connectMinio()
+		fatalIfErr(err)
+	}
+
+	// the cleanup does not need the uploaders of temp files
+	fileStorage, err := storage.NewStorageService(minioClient, nil)
+	fatalIfErr(err)
+
+	report, apiErr := fileStorage.CleanupTempFiles(context.Background(), *maxAge)
+	if apiErr != nil {
+		log.Fatal(apiErr)
+	}
+	fmt.Printf("Deleted %d temp uploads, reclaimed %d bytes\n", report.Files, report.Bytes)
+}
+
+// connectMinio connects to the MinIO server of the API
+func connectMinio() (*minio.Client, error) {
+	minioCfg := config.Get().Minio
+
+	return minio.New(minioCfg.Host+":"+strconv.Itoa(minioCfg.Port), &minio.Options{
+		Creds:  credentials.NewStaticV4(env.GetEnvString("ACCESS_KEY_MINIO", ""), env.GetEnvString("SECRET_KEY_MINIO", ""), ""),
+		Secure: minioCfg.UseSSL,
+	})
+}
+
+// fatalIfErr logs and exits if err is not nil
+func fatalIfErr(err error) {
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,2 @@
+DELETE FROM ticket_statuses WHERE title IN ('InProgress', 'WaitingOnCustomer', 'Resolved');
+ALTER TABLE ticket_statuses DROP COLUMN kind;
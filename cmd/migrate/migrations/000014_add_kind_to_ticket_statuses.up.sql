@@ -0,0 +1,8 @@
+ALTER TABLE ticket_statuses ADD COLUMN kind TEXT NOT NULL DEFAULT 'open';
+
+UPDATE ticket_statuses SET kind = 'closed' WHERE title = 'Close';
+UPDATE ticket_statuses SET kind = 'initial' WHERE title = 'Open';
+
+INSERT INTO ticket_statuses (title, description, kind) VALUES ('InProgress', 'An agent is working on the ticket', 'open');
+INSERT INTO ticket_statuses (title, description, kind) VALUES ('WaitingOnCustomer', 'Waiting for the requester to reply', 'pending');
+INSERT INTO ticket_statuses (title, description, kind) VALUES ('Resolved', 'The ticket is resolved and can be closed', 'resolved');
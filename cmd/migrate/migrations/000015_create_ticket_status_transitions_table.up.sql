@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS ticket_status_transitions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    from_status_id INTEGER NOT NULL,
+    to_status_id INTEGER NOT NULL,
+    status INT2 NOT NULL DEFAULT 1,
+    deleted INT2 NOT NULL DEFAULT 0,
+    UNIQUE(from_status_id, to_status_id),
+    FOREIGN KEY (from_status_id) REFERENCES ticket_statuses(id) ON DELETE CASCADE,
+    FOREIGN KEY (to_status_id) REFERENCES ticket_statuses(id) ON DELETE CASCADE
+);
+
+INSERT INTO ticket_status_transitions (from_status_id, to_status_id)
+SELECT f.id, t.id FROM ticket_statuses f, ticket_statuses t
+WHERE (f.title = 'Open' AND t.title IN ('InProgress', 'Close'))
+OR (f.title = 'InProgress' AND t.title IN ('WaitingOnCustomer', 'Resolved', 'Close'))
+OR (f.title = 'WaitingOnCustomer' AND t.title IN ('InProgress', 'Close'))
+OR (f.title = 'Resolved' AND t.title IN ('InProgress', 'Close'))
+OR (f.title = 'Close' AND t.title = 'InProgress');
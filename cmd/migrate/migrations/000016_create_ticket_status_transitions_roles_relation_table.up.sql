@@ -0,0 +1,22 @@
+-- A transition without any role relation can be performed by every user
+CREATE TABLE IF NOT EXISTS ticket_status_transitions_roles_relation (
+    transition_id INTEGER NOT NULL,
+    role_id INTEGER NOT NULL,
+    status INT2 NOT NULL DEFAULT 1,
+    deleted INT2 NOT NULL DEFAULT 0,
+    PRIMARY KEY (transition_id, role_id),
+    FOREIGN KEY (transition_id) REFERENCES ticket_status_transitions(id) ON DELETE CASCADE,
+    FOREIGN KEY (role_id) REFERENCES Roles(id) ON DELETE CASCADE
+);
+
+-- Only the staff role (BaseRole, the default of ticket.staff_role_ids) may start work on a
+-- ticket, wait on the requester, resolve or reopen a closed ticket. Requesters may still close
+-- their ticket and bring a pending or resolved one back into progress.
+INSERT INTO ticket_status_transitions_roles_relation (transition_id, role_id)
+SELECT tr.id, r.id FROM ticket_status_transitions tr
+JOIN ticket_statuses f ON f.id = tr.from_status_id
+JOIN ticket_statuses t ON t.id = tr.to_status_id
+JOIN roles r ON r.title = 'BaseRole'
+WHERE (f.title = 'Open' AND t.title = 'InProgress')
+OR (f.title = 'InProgress' AND t.title IN ('WaitingOnCustomer', 'Resolved'))
+OR (f.title = 'Close' AND t.title = 'InProgress');
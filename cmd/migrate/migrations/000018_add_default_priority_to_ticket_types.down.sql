@@ -0,0 +1 @@
+ALTER TABLE ticket_types DROP COLUMN default_priority;
@@ -0,0 +1 @@
+ALTER TABLE ticket_types ADD COLUMN default_priority INTEGER;
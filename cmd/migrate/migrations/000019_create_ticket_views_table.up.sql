@@ -0,0 +1,19 @@
+-- Saved GetTickets filters. query holds the JSON of the TicketQueryParams,
+-- shared views are visible to every user of department_id.
+CREATE TABLE IF NOT EXISTS ticket_views (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    department_id INTEGER NOT NULL,
+    title TEXT NOT NULL,
+    query TEXT NOT NULL,
+    shared INT2 NOT NULL DEFAULT 0,
+    created_at TEXT NOT NULL DEFAULT (datetime('now')),
+    updated_at TEXT NOT NULL DEFAULT (datetime('now')),
+    status INT2 NOT NULL DEFAULT 1,
+    deleted INT2 NOT NULL DEFAULT 0,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (department_id) REFERENCES departments(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ticket_views_user_id ON ticket_views (user_id);
+CREATE INDEX IF NOT EXISTS idx_ticket_views_department_id ON ticket_views (department_id);
@@ -0,0 +1,11 @@
+-- Tag catalog. A tag without department_id can be used in every department.
+CREATE TABLE IF NOT EXISTS tags (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title TEXT NOT NULL,
+    color TEXT NOT NULL DEFAULT '#808080',
+    department_id INTEGER,
+    status INT2 NOT NULL DEFAULT 1,
+    deleted INT2 NOT NULL DEFAULT 0,
+    UNIQUE(title, department_id),
+    FOREIGN KEY (department_id) REFERENCES departments(id) ON DELETE CASCADE
+);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS canned_responses;
@@ -0,0 +1,20 @@
+-- Reply templates of agents. A template without department_id or ticket_type_id can be
+-- used on tickets of every department or type. body may hold {{username}}, {{trackCode}}
+-- and {{departmentName}} placeholders, rendered when the template is posted.
+CREATE TABLE IF NOT EXISTS canned_responses (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title TEXT NOT NULL,
+    body TEXT NOT NULL,
+    department_id INTEGER,
+    ticket_type_id INTEGER,
+    created_by INTEGER NOT NULL,
+    created_at TEXT NOT NULL DEFAULT (datetime('now')),
+    updated_at TEXT NOT NULL DEFAULT (datetime('now')),
+    status INT2 NOT NULL DEFAULT 1,
+    deleted INT2 NOT NULL DEFAULT 0,
+    FOREIGN KEY (department_id) REFERENCES departments(id) ON DELETE CASCADE,
+    FOREIGN KEY (ticket_type_id) REFERENCES ticket_types(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_canned_responses_department_id ON canned_responses (department_id);
@@ -0,0 +1,12 @@
+-- One row each time an agent posts a canned response, for usage statistics
+CREATE TABLE IF NOT EXISTS canned_response_uses (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    canned_response_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    ticket_id TEXT NOT NULL,
+    used_at TEXT NOT NULL DEFAULT (datetime('now')),
+    FOREIGN KEY (canned_response_id) REFERENCES canned_responses(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_canned_response_uses_used_at ON canned_response_uses (used_at);
@@ -0,0 +1,77 @@
+// Command migratechat moves the chat messages embedded in ticket documents into the chat collection
+// and fills in the search text and sort fields of tickets created before they were stored.
+// It is safe to run while the API is up and to rerun after a failure.
+//
+//	go run ./cmd/migratechat
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"ticket-api/internal/config"
+	"ticket-api/internal/env"
+	"ticket-api/internal/repository"
+	"time"
+
+	_ "github.com/joho/godotenv/autoload"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+func main() {
+	config.Load("config.yaml")
+	if !config.Get().Mongo.Enable {
+		log.Fatal("MongoDB is disabled in config.yaml")
+	}
+
+	db, err := connectMongo()
+	fatalIfErr(err)
+	defer db.Client().Disconnect(context.Background())
+
+	// storage and history are not used by the migration
+	chatRepo := repository.NewChatRepository(db, nil, nil)
+
+	tickets, messages, err := chatRepo.MigrateEmbeddedChats(context.Background())
+	fmt.Printf("Migrated %d messages of %d tickets\n", messages, tickets)
+	fatalIfErr(err)
+
+	indexed, err := chatRepo.BackfillSearchText(context.Background())
+	fmt.Printf("Built the search text of %d tickets\n", indexed)
+	fatalIfErr(err)
+
+	ticketRepo := repository.NewTicketRepository(db, nil, nil)
+	sortable, err := ticketRepo.BackfillSortFields(context.Background())
+	fmt.Printf("Set the missing priority of %d tickets\n", sortable)
+	fatalIfErr(err)
+}
+
+// connectMongo connects to the MongoDB database of the API
+func connectMongo() (*mongo.Database, error) {
+	uri := os.Getenv("MONGODB_URI")
+	if uri == "" {
+		return nil, errors.New("MONGODB_URI is not set")
+	}
+
+	client, err := mongo.Connect(options.Client().ApplyURI(uri))
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx, nil); err != nil {
+		return nil, err
+	}
+
+	return client.Database(env.GetEnvString("MONGODB_DB", config.Get().Mongo.DBName)), nil
+}
+
+// fatalIfErr logs and exits if err is not nil
+func fatalIfErr(err error) {
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,33 @@
+-- name: AddCannedResponse :one
+INSERT INTO canned_responses (title, body, department_id, ticket_type_id, created_by) VALUES (?, ?, ?, ?, ?) RETURNING id;
+
+-- name: UpdateCannedResponse :execrows
+UPDATE canned_responses
+SET title = ?, body = ?, department_id = ?, ticket_type_id = ?, status = ?, updated_at = datetime('now')
+WHERE id = ?
+AND deleted = 0;
+
+-- name: DeleteCannedResponse :execrows
+UPDATE canned_responses
+SET deleted = 1, updated_at = datetime('now')
+WHERE id = ?
+AND deleted = 0;
+
+-- name: GetAllCannedResponses :many
+SELECT * FROM canned_responses
+WHERE deleted = 0
+ORDER BY title;
+
+-- name: AddCannedResponseUse :exec
+INSERT INTO canned_response_uses (canned_response_id, user_id, ticket_id) VALUES (?, ?, ?);
+
+-- name: GetCannedResponseUsage :many
+SELECT canned_response_id,
+       COUNT(*) AS uses,
+       COUNT(DISTINCT user_id) AS agents,
+       CAST(MAX(used_at) AS TEXT) AS last_used_at
+FROM canned_response_uses
+WHERE used_at >= sqlc.arg(used_from)
+AND used_at < sqlc.arg(used_to)
+GROUP BY canned_response_id
+ORDER BY uses DESC;
@@ -0,0 +1,25 @@
+CREATE TABLE canned_responses (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title TEXT NOT NULL,
+    body TEXT NOT NULL,
+    department_id INTEGER,
+    ticket_type_id INTEGER,
+    created_by INTEGER NOT NULL,
+    created_at TEXT NOT NULL DEFAULT (datetime('now')),
+    updated_at TEXT NOT NULL DEFAULT (datetime('now')),
+    status INT2 NOT NULL DEFAULT 1,
+    deleted INT2 NOT NULL DEFAULT 0,
+    FOREIGN KEY (department_id) REFERENCES departments(id) ON DELETE CASCADE,
+    FOREIGN KEY (ticket_type_id) REFERENCES ticket_types(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id)
+);
+
+CREATE TABLE canned_response_uses (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    canned_response_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    ticket_id TEXT NOT NULL,
+    used_at TEXT NOT NULL DEFAULT (datetime('now')),
+    FOREIGN KEY (canned_response_id) REFERENCES canned_responses(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
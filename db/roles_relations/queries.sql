@@ -10,6 +10,9 @@ INSERT INTO ticket_types_roles_relation (ticket_type_id, role_id) VALUES (?, ?);
 -- name: AddAPIKeysToRolesRelation :exec
 INSERT INTO api_keys_roles_relation (api_key_id, role_id) VALUES (?, ?);
 
+-- name: AddTicketStatusTransitionsToRolesRelation :exec
+INSERT INTO ticket_status_transitions_roles_relation (transition_id, role_id) VALUES (?, ?);
+
 -- name: GetAPIKeyRoleIDs :many
 SELECT role_id FROM api_keys_roles_relation
 WHERE deleted = 0
@@ -21,3 +24,15 @@ SELECT role_id FROM api_routes_roles_relation
 WHERE deleted = 0
 AND status != 0
 AND api_route_id = ?;
+
+-- name: GetTicketStatusTransitionRoleIDs :many
+SELECT role_id FROM ticket_status_transitions_roles_relation
+WHERE deleted = 0
+AND status != 0
+AND transition_id = ?;
+
+-- name: GetUserRoleIDs :many
+SELECT role_id FROM users_roles_relation
+WHERE deleted = 0
+AND status != 0
+AND user_id = ?;
@@ -37,3 +37,13 @@ CREATE TABLE api_keys_roles_relation (
     FOREIGN KEY (api_key_id) REFERENCES api_Keys(id) ON DELETE CASCADE,
     FOREIGN KEY (rol_id) REFERENCES Roles(id) ON DELETE CASCADE
 );
+
+CREATE TABLE ticket_status_transitions_roles_relation (
+    transition_id INTEGER NOT NULL,
+    role_id INTEGER NOT NULL,
+    status INT2 NOT NULL DEFAULT 1,
+    deleted INT2 NOT NULL DEFAULT 0,
+    PRIMARY KEY (transition_id, role_id),
+    FOREIGN KEY (transition_id) REFERENCES ticket_status_transitions(id) ON DELETE CASCADE,
+    FOREIGN KEY (role_id) REFERENCES Roles(id) ON DELETE CASCADE
+);
@@ -0,0 +1,13 @@
+-- name: AddSLAPolicy :one
+INSERT INTO sla_policies (ticket_type_id, department_id, priority, first_response_minutes, resolve_minutes)
+VALUES (?, ?, ?, ?, ?) RETURNING id;
+
+-- name: GetMatchingSLAPolicy :one
+SELECT * FROM sla_policies
+WHERE deleted = 0
+AND status != 0
+AND (ticket_type_id IS NULL OR ticket_type_id = ?)
+AND (department_id IS NULL OR department_id = ?)
+AND (priority IS NULL OR priority = ?)
+ORDER BY (ticket_type_id IS NOT NULL) + (department_id IS NOT NULL) + (priority IS NOT NULL) DESC, id
+LIMIT 1;
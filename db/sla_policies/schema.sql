@@ -0,0 +1,13 @@
+CREATE TABLE sla_policies (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    ticket_type_id INTEGER,
+    department_id INTEGER,
+    priority INTEGER,
+    first_response_minutes INTEGER NOT NULL,
+    resolve_minutes INTEGER NOT NULL,
+    status INT2 NOT NULL DEFAULT 1,
+    deleted INT2 NOT NULL DEFAULT 0,
+    UNIQUE(ticket_type_id, department_id, priority),
+    FOREIGN KEY (ticket_type_id) REFERENCES ticket_types(id) ON DELETE CASCADE,
+    FOREIGN KEY (department_id) REFERENCES departments(id) ON DELETE CASCADE
+);
@@ -0,0 +1,13 @@
+-- name: AddTag :one
+INSERT INTO tags (title, color, department_id) VALUES (?, ?, ?) RETURNING id;
+
+-- name: UpdateTag :execrows
+UPDATE tags
+SET title = ?, color = ?, department_id = ?, status = ?
+WHERE id = ?
+AND deleted = 0;
+
+-- name: GetAllTags :many
+SELECT * FROM tags
+WHERE deleted = 0
+ORDER BY title;
@@ -1,5 +1,5 @@
 -- name: AddTicketStatus :exec
-INSERT INTO ticket_statuses (title, description) VALUES (?, ?);
+INSERT INTO ticket_statuses (title, description, kind) VALUES (?, ?, ?);
 
 -- name: GetAllActiveTicketStatuses :many
 SELECT * FROM ticket_statuses
@@ -11,3 +11,13 @@ SELECT * FROM ticket_statuses
 WHERE deleted = 0
 AND status != 0
 AND id = ?;
+
+-- name: AddTicketStatusTransition :one
+INSERT INTO ticket_status_transitions (from_status_id, to_status_id) VALUES (?, ?) RETURNING id;
+
+-- name: GetActiveTicketStatusTransition :one
+SELECT * FROM ticket_status_transitions
+WHERE deleted = 0
+AND status != 0
+AND from_status_id = ?
+AND to_status_id = ?;
@@ -3,5 +3,17 @@ CREATE TABLE ticket_statuses (
   title TEXT NOT NULL UNIQUE,
   description TEXT,
   status INT2 NOT NULL DEFAULT 1,
-  deleted INT2 NOT NULL DEFAULT 0
+  deleted INT2 NOT NULL DEFAULT 0,
+  kind TEXT NOT NULL DEFAULT 'open'
+);
+
+CREATE TABLE ticket_status_transitions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    from_status_id INTEGER NOT NULL,
+    to_status_id INTEGER NOT NULL,
+    status INT2 NOT NULL DEFAULT 1,
+    deleted INT2 NOT NULL DEFAULT 0,
+    UNIQUE(from_status_id, to_status_id),
+    FOREIGN KEY (from_status_id) REFERENCES ticket_statuses(id) ON DELETE CASCADE,
+    FOREIGN KEY (to_status_id) REFERENCES ticket_statuses(id) ON DELETE CASCADE
 );
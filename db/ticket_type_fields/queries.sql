@@ -0,0 +1,21 @@
+-- name: AddTicketTypeField :one
+INSERT INTO ticket_type_fields (ticket_type_id, name, label, field_type, required, pattern, options, sort_order)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?) RETURNING id;
+
+-- name: DeleteTicketTypeField :execrows
+UPDATE ticket_type_fields
+SET deleted = 1
+WHERE id = ?
+AND deleted = 0;
+
+-- name: GetTicketTypeFieldByID :one
+SELECT * FROM ticket_type_fields
+WHERE id = ?
+AND deleted = 0;
+
+-- name: GetActiveTicketTypeFields :many
+SELECT * FROM ticket_type_fields
+WHERE ticket_type_id = ?
+AND status = 1
+AND deleted = 0
+ORDER BY sort_order, id;
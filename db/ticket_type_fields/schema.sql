@@ -0,0 +1,15 @@
+CREATE TABLE ticket_type_fields (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    ticket_type_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    label TEXT NOT NULL,
+    field_type TEXT NOT NULL,
+    required INT2 NOT NULL DEFAULT 0,
+    pattern TEXT,
+    options TEXT,
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    status INT2 NOT NULL DEFAULT 1,
+    deleted INT2 NOT NULL DEFAULT 0,
+    UNIQUE(ticket_type_id, name),
+    FOREIGN KEY (ticket_type_id) REFERENCES ticket_types(id) ON DELETE CASCADE
+);
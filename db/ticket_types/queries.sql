@@ -17,3 +17,8 @@ FROM ticket_types
 WHERE deleted = 0
 AND status != 0
 AND id = ?;
+
+-- name: GetTicketTypeDefaultPriority :one
+SELECT default_priority FROM ticket_types
+WHERE deleted = 0
+AND id = ?;
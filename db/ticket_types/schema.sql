@@ -3,5 +3,6 @@ CREATE TABLE ticket_types(
     title TEXT NOT NULL UNIQUE,
     description TEXT,
     status INT2 NOT NULL DEFAULT 1,
-    deleted INT2 NOT NULL DEFAULT 0
+    deleted INT2 NOT NULL DEFAULT 0,
+    default_priority INTEGER
 );
\ No newline at end of file
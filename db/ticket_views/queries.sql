@@ -0,0 +1,29 @@
+-- name: AddTicketView :one
+INSERT INTO ticket_views (user_id, department_id, title, query, shared) VALUES (?, ?, ?, ?, ?) RETURNING id;
+
+-- name: UpdateTicketView :execrows
+UPDATE ticket_views
+SET title = ?, query = ?, shared = ?, department_id = ?, updated_at = datetime('now')
+WHERE id = ?
+AND user_id = ?
+AND deleted = 0;
+
+-- name: DeleteTicketView :execrows
+UPDATE ticket_views
+SET deleted = 1, updated_at = datetime('now')
+WHERE id = ?
+AND user_id = ?
+AND deleted = 0;
+
+-- name: GetTicketViewByID :one
+SELECT * FROM ticket_views
+WHERE id = ?
+AND deleted = 0
+AND status != 0;
+
+-- name: GetVisibleTicketViews :many
+SELECT * FROM ticket_views
+WHERE deleted = 0
+AND status != 0
+AND (user_id = ? OR (shared = 1 AND department_id = ?))
+ORDER BY title;
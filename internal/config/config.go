@@ -2,8 +2,11 @@
 package config
 
 import (
+	"fmt"
 	"log"
+	"mime"
 	"os"
+	"strings"
 	"sync"
 
 	"github.com/fsnotify/fsnotify"
@@ -54,9 +57,11 @@ type Config struct {
 	} `yaml:"one_time_token"`
 
 	Cache struct {
-		TicketTypeTTL   int64 `yaml:"ticket_type_ttl_minutes"`
-		DepartmentTTL   int64 `yaml:"department_ttl_minutes"`
-		TicketStatusTTL int64 `yaml:"ticket_status_ttl_minutes"`
+		TicketTypeTTL     int64 `yaml:"ticket_type_ttl_minutes"`
+		DepartmentTTL     int64 `yaml:"department_ttl_minutes"`
+		TicketStatusTTL   int64 `yaml:"ticket_status_ttl_minutes"`
+		TagTTL            int64 `yaml:"tag_ttl_minutes"`
+		CannedResponseTTL int64 `yaml:"canned_response_ttl_minutes"`
 	} `yaml:"cache"`
 
 	Auth struct {
@@ -65,9 +70,11 @@ type Config struct {
 	} `yaml:"auth"`
 
 	Mongo struct {
-		Enable               bool   `yaml:"enable"`                  // Enable MongoDB integration
-		DBName               string `yaml:"db_name"`                 // MongoDB database name
-		TicketCollectionName string `yaml:"ticket_collocation_name"` // MongoDB collection name for tickets
+		Enable                      bool   `yaml:"enable"`                         // Enable MongoDB integration
+		DBName                      string `yaml:"db_name"`                        // MongoDB database name
+		TicketCollectionName        string `yaml:"ticket_collocation_name"`        // MongoDB collection name for tickets
+		TicketHistoryCollectionName string `yaml:"ticket_history_collection_name"` // MongoDB collection name for ticket history events
+		ChatCollectionName          string `yaml:"chat_collection_name"`           // MongoDB collection name for ticket chat messages
 	} `yaml:"mongo"`
 
 	Redis struct {
@@ -77,8 +84,21 @@ type Config struct {
 		DB     int    `yaml:"db"`     // Redis logical database (integer 0 - 15)
 	} `yaml:"redis"`
 
+	Storage struct {
+		Driver string `yaml:"driver"` // minio or local
+		Local  struct {
+			Root        string `yaml:"root"`         // Directory of the stored files
+			DownloadURL string `yaml:"download_url"` // Public URL of the files/Download/ route, signed links are built on it
+		} `yaml:"local"`
+		TempUploadTTL int `yaml:"temp_upload_ttl_minutes"` // How long the uploader may attach a temp upload (minutes)
+		TempCleanup   struct {
+			Enable          bool `yaml:"enable"`           // Delete temp uploads never attached to a ticket
+			IntervalMinutes int  `yaml:"interval_minutes"` // How often the leader instance cleans up (minutes)
+			MaxAgeMinutes   int  `yaml:"max_age_minutes"`  // Age after which a temp upload is deleted (minutes)
+		} `yaml:"temp_cleanup"`
+	} `yaml:"storage"`
+
 	Minio struct {
-		Enable bool   `yaml:"enable"`
 		Host   string `yaml:"host"`
 		Port   int    `yaml:"port"`
 		Bucket string `yaml:"bucket"`
@@ -86,13 +106,18 @@ type Config struct {
 	} `yaml:"minio"`
 
 	TicketConfig struct {
-		MaxPagingSize            int      `yaml:"max_paging_size"`
-		MinPagingSize            int      `yaml:"min_paging_size"`
-		DefaultPagingSize        int      `yaml:"default_paging_size"`
-		MaxCountingItem          int64    `yaml:"max_counting_item"`
-		MaxTicketUploadFile      int      `yaml:"max_ticket_upload_file"`
-		MaxTicketUploadFileSize  int64    `yaml:"max_ticket_upload_file_size"`
-		AcceptableFilesForUpload []string `yaml:"acceptable_files_for_upload"`
+		MaxPagingSize            int             `yaml:"max_paging_size"`
+		MinPagingSize            int             `yaml:"min_paging_size"`
+		DefaultPagingSize        int             `yaml:"default_paging_size"`
+		MaxCountingItem          int64           `yaml:"max_counting_item"`
+		MaxTicketUploadFile      int             `yaml:"max_ticket_upload_file"`
+		MaxTicketUploadFileSize  int64           `yaml:"max_ticket_upload_file_size"`
+		AcceptableFilesForUpload UploadFileTypes `yaml:"acceptable_files_for_upload"` // MIME types the content of an upload may have, per file extension
+		DefaultPriority          int             `yaml:"default_priority"`            // Priority used when neither a user rule nor a ticket type default exists
+		StaffRoleIDs             []int64         `yaml:"staff_role_ids"`              // Roles allowed to perform staff-only ticket operations
+		MaxBulkSize              int             `yaml:"max_bulk_size"`               // Maximum number of tickets of one bulk operation
+		ChatEditWindowMinutes    int             `yaml:"chat_edit_window_minutes"`    // How long after sending a message its sender may edit or delete it
+		StreamHeartbeatSeconds   int             `yaml:"stream_heartbeat_seconds"`    // Interval of keep-alive messages on ticket event streams and live chats
 	} `yaml:"ticket"`
 }
 
@@ -103,6 +128,32 @@ var (
 )
 
 // Load reads config file initially.
+// UploadFileTypes maps the accepted file extensions to the MIME types their content may
+// have. The older list form of extensions is still accepted, each extension then allows
+// the MIME type registered for it.
+type UploadFileTypes map[string][]string
+
+func (t *UploadFileTypes) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind != yaml.SequenceNode {
+		return value.Decode((*map[string][]string)(t))
+	}
+
+	var exts []string
+	if err := value.Decode(&exts); err != nil {
+		return err
+	}
+	types := make(UploadFileTypes, len(exts))
+	for _, ext := range exts {
+		mimeType, _, _ := strings.Cut(mime.TypeByExtension(ext), ";")
+		if mimeType == "" {
+			return fmt.Errorf("no MIME type known for upload extension %q, list it as %s: [\"<mime type>\"]", ext, ext)
+		}
+		types[ext] = []string{mimeType}
+	}
+	*t = types
+	return nil
+}
+
 func Load(p string) *Config {
 	path = p
 	cfg = &Config{}
@@ -18,6 +18,13 @@ type ApiRoutesRolesRelation struct {
 	Deleted    int64
 }
 
+type TicketStatusTransitionsRolesRelation struct {
+	TransitionID int64
+	RoleID       int64
+	Status       int64
+	Deleted      int64
+}
+
 type TicketTypesRolesRelation struct {
 	TicketTypeID int64
 	RoleID       int64
@@ -37,6 +37,20 @@ func (q *Queries) AddApiRoutesToRolesRelation(ctx context.Context, arg AddApiRou
 	return err
 }
 
+const addTicketStatusTransitionsToRolesRelation = `-- name: AddTicketStatusTransitionsToRolesRelation :exec
+INSERT INTO ticket_status_transitions_roles_relation (transition_id, role_id) VALUES (?, ?)
+`
+
+type AddTicketStatusTransitionsToRolesRelationParams struct {
+	TransitionID int64
+	RoleID       int64
+}
+
+func (q *Queries) AddTicketStatusTransitionsToRolesRelation(ctx context.Context, arg AddTicketStatusTransitionsToRolesRelationParams) error {
+	_, err := q.db.ExecContext(ctx, addTicketStatusTransitionsToRolesRelation, arg.TransitionID, arg.RoleID)
+	return err
+}
+
 const addTicketTypesToRolesRelation = `-- name: AddTicketTypesToRolesRelation :exec
 INSERT INTO ticket_types_roles_relation (ticket_type_id, role_id) VALUES (?, ?)
 `
@@ -124,3 +138,63 @@ func (q *Queries) GetAPIRouteRoleIDs(ctx context.Context, apiRouteID int64) ([]i
 	}
 	return items, nil
 }
+
+const getTicketStatusTransitionRoleIDs = `-- name: GetTicketStatusTransitionRoleIDs :many
+SELECT role_id FROM ticket_status_transitions_roles_relation
+WHERE deleted = 0
+AND status != 0
+AND transition_id = ?
+`
+
+func (q *Queries) GetTicketStatusTransitionRoleIDs(ctx context.Context, transitionID int64) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, getTicketStatusTransitionRoleIDs, transitionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var role_id int64
+		if err := rows.Scan(&role_id); err != nil {
+			return nil, err
+		}
+		items = append(items, role_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserRoleIDs = `-- name: GetUserRoleIDs :many
+SELECT role_id FROM users_roles_relation
+WHERE deleted = 0
+AND status != 0
+AND user_id = ?
+`
+
+func (q *Queries) GetUserRoleIDs(ctx context.Context, userID int64) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, getUserRoleIDs, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var role_id int64
+		if err := rows.Scan(&role_id); err != nil {
+			return nil, err
+		}
+		items = append(items, role_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
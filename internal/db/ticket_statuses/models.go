@@ -14,4 +14,13 @@ type TicketStatus struct {
 	Description sql.NullString
 	Status      int64
 	Deleted     int64
+	Kind        string
+}
+
+type TicketStatusTransition struct {
+	ID           int64
+	FromStatusID int64
+	ToStatusID   int64
+	Status       int64
+	Deleted      int64
 }
@@ -11,21 +11,38 @@ import (
 )
 
 const addTicketStatus = `-- name: AddTicketStatus :exec
-INSERT INTO ticket_statuses (title, description) VALUES (?, ?)
+INSERT INTO ticket_statuses (title, description, kind) VALUES (?, ?, ?)
 `
 
 type AddTicketStatusParams struct {
 	Title       string
 	Description sql.NullString
+	Kind        string
 }
 
 func (q *Queries) AddTicketStatus(ctx context.Context, arg AddTicketStatusParams) error {
-	_, err := q.db.ExecContext(ctx, addTicketStatus, arg.Title, arg.Description)
+	_, err := q.db.ExecContext(ctx, addTicketStatus, arg.Title, arg.Description, arg.Kind)
 	return err
 }
 
+const addTicketStatusTransition = `-- name: AddTicketStatusTransition :one
+INSERT INTO ticket_status_transitions (from_status_id, to_status_id) VALUES (?, ?) RETURNING id
+`
+
+type AddTicketStatusTransitionParams struct {
+	FromStatusID int64
+	ToStatusID   int64
+}
+
+func (q *Queries) AddTicketStatusTransition(ctx context.Context, arg AddTicketStatusTransitionParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, addTicketStatusTransition, arg.FromStatusID, arg.ToStatusID)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
 const getActiveTicketStatusById = `-- name: GetActiveTicketStatusById :one
-SELECT id, title, description, status, deleted FROM ticket_statuses
+SELECT id, title, description, status, deleted, kind FROM ticket_statuses
 WHERE deleted = 0
 AND status != 0
 AND id = ?
@@ -40,12 +57,39 @@ func (q *Queries) GetActiveTicketStatusById(ctx context.Context, id int64) (Tick
 		&i.Description,
 		&i.Status,
 		&i.Deleted,
+		&i.Kind,
+	)
+	return i, err
+}
+
+const getActiveTicketStatusTransition = `-- name: GetActiveTicketStatusTransition :one
+SELECT id, from_status_id, to_status_id, status, deleted FROM ticket_status_transitions
+WHERE deleted = 0
+AND status != 0
+AND from_status_id = ?
+AND to_status_id = ?
+`
+
+type GetActiveTicketStatusTransitionParams struct {
+	FromStatusID int64
+	ToStatusID   int64
+}
+
+func (q *Queries) GetActiveTicketStatusTransition(ctx context.Context, arg GetActiveTicketStatusTransitionParams) (TicketStatusTransition, error) {
+	row := q.db.QueryRowContext(ctx, getActiveTicketStatusTransition, arg.FromStatusID, arg.ToStatusID)
+	var i TicketStatusTransition
+	err := row.Scan(
+		&i.ID,
+		&i.FromStatusID,
+		&i.ToStatusID,
+		&i.Status,
+		&i.Deleted,
 	)
 	return i, err
 }
 
 const getAllActiveTicketStatuses = `-- name: GetAllActiveTicketStatuses :many
-SELECT id, title, description, status, deleted FROM ticket_statuses
+SELECT id, title, description, status, deleted, kind FROM ticket_statuses
 WHERE deleted = 0
 AND status != 0
 `
@@ -65,6 +109,7 @@ func (q *Queries) GetAllActiveTicketStatuses(ctx context.Context) ([]TicketStatu
 			&i.Description,
 			&i.Status,
 			&i.Deleted,
+			&i.Kind,
 		); err != nil {
 			return nil, err
 		}
@@ -9,9 +9,10 @@ import (
 )
 
 type TicketType struct {
-	ID          int64
-	Title       string
-	Description sql.NullString
-	Status      int64
-	Deleted     int64
+	ID              int64
+	Title           string
+	Description     sql.NullString
+	Status          int64
+	Deleted         int64
+	DefaultPriority sql.NullInt64
 }
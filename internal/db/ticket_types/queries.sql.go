@@ -42,7 +42,7 @@ func (q *Queries) CheckTicketTypeByID(ctx context.Context, id int64) (int64, err
 }
 
 const getAllActiveTicketTypes = `-- name: GetAllActiveTicketTypes :many
-SELECT id, title, description, status, deleted FROM ticket_types
+SELECT id, title, description, status, deleted, default_priority FROM ticket_types
 WHERE deleted = 0
 AND status != 0
 `
@@ -62,6 +62,7 @@ func (q *Queries) GetAllActiveTicketTypes(ctx context.Context) ([]TicketType, er
 			&i.Description,
 			&i.Status,
 			&i.Deleted,
+			&i.DefaultPriority,
 		); err != nil {
 			return nil, err
 		}
@@ -77,7 +78,7 @@ func (q *Queries) GetAllActiveTicketTypes(ctx context.Context) ([]TicketType, er
 }
 
 const getAllTicketTypes = `-- name: GetAllTicketTypes :many
-SELECT id, title, description, status, deleted FROM ticket_types
+SELECT id, title, description, status, deleted, default_priority FROM ticket_types
 WHERE deleted = 0
 `
 
@@ -96,6 +97,7 @@ func (q *Queries) GetAllTicketTypes(ctx context.Context) ([]TicketType, error) {
 			&i.Description,
 			&i.Status,
 			&i.Deleted,
+			&i.DefaultPriority,
 		); err != nil {
 			return nil, err
 		}
@@ -109,3 +111,16 @@ func (q *Queries) GetAllTicketTypes(ctx context.Context) ([]TicketType, error) {
 	}
 	return items, nil
 }
+
+const getTicketTypeDefaultPriority = `-- name: GetTicketTypeDefaultPriority :one
+SELECT default_priority FROM ticket_types
+WHERE deleted = 0
+AND id = ?
+`
+
+func (q *Queries) GetTicketTypeDefaultPriority(ctx context.Context, id int64) (sql.NullInt64, error) {
+	row := q.db.QueryRowContext(ctx, getTicketTypeDefaultPriority, id)
+	var default_priority sql.NullInt64
+	err := row.Scan(&default_priority)
+	return default_priority, err
+}
@@ -0,0 +1,128 @@
+package dto
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"ticket-api/internal/db/canned_responses"
+	"time"
+)
+
+// Placeholders of a canned response body, rendered when an agent posts it
+const (
+	CannedPlaceholderUsername       = "username"       // username of the ticket creator
+	CannedPlaceholderTrackCode      = "trackCode"      // track code of the ticket
+	CannedPlaceholderDepartmentName = "departmentName" // title of the ticket's department
+)
+
+var cannedPlaceholderPattern = regexp.MustCompile(`\{\{\s*(\w+)\s*\}\}`)
+
+// CannedResponseRequest is the payload for adding a canned response
+type CannedResponseRequest struct {
+	Title        string `json:"title" binding:"required,max=100"`
+	Body         string `json:"body" binding:"required,max=4000"`
+	DepartmentID *int64 `json:"departmentId,omitempty"` // nil makes the response usable in every department
+	TicketTypeID *int64 `json:"ticketTypeId,omitempty"` // nil makes the response usable on every ticket type
+}
+
+// Validate rejects bodies with placeholders that cannot be rendered
+func (r *CannedResponseRequest) Validate() error {
+	for _, match := range cannedPlaceholderPattern.FindAllStringSubmatch(r.Body, -1) {
+		switch match[1] {
+		case CannedPlaceholderUsername, CannedPlaceholderTrackCode, CannedPlaceholderDepartmentName:
+		default:
+			return fmt.Errorf("unknown placeholder %s", match[0])
+		}
+	}
+	return nil
+}
+
+// CannedResponseUpdateRequest replaces the fields of a canned response
+type CannedResponseUpdateRequest struct {
+	ID     int64 `json:"id" binding:"required"`
+	Active bool  `json:"active"` // inactive responses are kept but can no longer be posted
+	CannedResponseRequest
+}
+
+// CannedResponseQuery selects the canned responses usable on tickets of a department and type
+type CannedResponseQuery struct {
+	DepartmentID    int64 `form:"departmentId"`    // 0 matches every department
+	TicketTypeID    int64 `form:"ticketTypeId"`    // 0 matches every ticket type
+	IncludeInactive bool  `form:"includeInactive"` // also list deactivated responses
+}
+
+// CannedResponseDTO is a canned response of the library
+type CannedResponseDTO struct {
+	ID           int64  `json:"id"`
+	Title        string `json:"title"`
+	Body         string `json:"body"`
+	DepartmentID *int64 `json:"departmentId,omitempty"`
+	TicketTypeID *int64 `json:"ticketTypeId,omitempty"`
+	CreatedBy    int64  `json:"createdBy"`
+	Active       bool   `json:"active"`
+	CreatedAt    string `json:"createdAt"`
+	UpdatedAt    string `json:"updatedAt"`
+}
+
+// ToCannedResponseDTO converts a canned_responses.CannedResponse
+func ToCannedResponseDTO(m *canned_responses.CannedResponse) *CannedResponseDTO {
+	var departmentID, ticketTypeID *int64
+	if m.DepartmentID.Valid {
+		departmentID = &m.DepartmentID.Int64
+	}
+	if m.TicketTypeID.Valid {
+		ticketTypeID = &m.TicketTypeID.Int64
+	}
+
+	return &CannedResponseDTO{
+		ID:           m.ID,
+		Title:        m.Title,
+		Body:         m.Body,
+		DepartmentID: departmentID,
+		TicketTypeID: ticketTypeID,
+		CreatedBy:    m.CreatedBy,
+		Active:       m.Status == 1,
+		CreatedAt:    m.CreatedAt,
+		UpdatedAt:    m.UpdatedAt,
+	}
+}
+
+// CannedResponseValues are the values of the placeholders for one ticket
+type CannedResponseValues struct {
+	Username       string
+	TrackCode      string
+	DepartmentName string
+}
+
+// RenderCannedResponse replaces the placeholders of a canned response body
+func RenderCannedResponse(body string, values CannedResponseValues) string {
+	return cannedPlaceholderPattern.ReplaceAllStringFunc(body, func(placeholder string) string {
+		name := strings.TrimSpace(strings.Trim(placeholder, "{}"))
+		switch name {
+		case CannedPlaceholderUsername:
+			return values.Username
+		case CannedPlaceholderTrackCode:
+			return values.TrackCode
+		case CannedPlaceholderDepartmentName:
+			return values.DepartmentName
+		}
+		return placeholder
+	})
+}
+
+// CannedResponseUsageRequest filters the usage statistics of canned responses
+type CannedResponseUsageRequest struct {
+	From         *time.Time `json:"from,omitempty"`         // defaults to 30 days before to
+	To           *time.Time `json:"to,omitempty"`           // defaults to now
+	DepartmentID *int64     `json:"departmentId,omitempty"` // only responses of this department and global ones
+}
+
+// CannedResponseUsageDTO is how often a canned response was posted
+type CannedResponseUsageDTO struct {
+	CannedResponseID int64  `json:"cannedResponseId"`
+	Title            string `json:"title"`
+	DepartmentID     *int64 `json:"departmentId,omitempty"`
+	Uses             int64  `json:"uses"`
+	Agents           int64  `json:"agents"` // distinct agents who posted it
+	LastUsedAt       string `json:"lastUsedAt"`
+}
@@ -12,8 +12,63 @@ type ChatMessageDTO struct {
 	SenderID    int64     `json:"senderId"`
 	Message     string    `json:"message"`
 	Attachments []string  `json:"attachments,omitempty"`
+	Internal    bool      `json:"internal,omitempty"` // staff-only note, never shown to the requester
 	CreatedAt   time.Time `json:"createdAt"`
 	UpdatedAt   time.Time `json:"updatedAt"`
+
+	Edited    bool              `json:"edited,omitempty"`    // the sender changed the text after sending
+	Deleted   bool              `json:"deleted,omitempty"`   // the sender deleted the message, its text and attachments are hidden
+	Revisions []ChatRevisionDTO `json:"revisions,omitempty"` // previous texts, oldest first
+}
+
+// ChatRevisionDTO is a previous text of an edited chat message
+type ChatRevisionDTO struct {
+	Message  string    `json:"message"`
+	EditedAt time.Time `json:"editedAt"`
+}
+
+// ToChatMessageDTO converts a model.ChatMessage, hiding the content of deleted messages
+func ToChatMessageDTO(msg *model.ChatMessage) ChatMessageDTO {
+	if msg.IsDeleted() {
+		return ChatMessageDTO{
+			ID:        msg.ID,
+			SenderID:  msg.SenderID,
+			Internal:  msg.Internal,
+			CreatedAt: msg.CreatedAt,
+			UpdatedAt: msg.UpdatedAt,
+			Deleted:   true,
+		}
+	}
+
+	var revisions []ChatRevisionDTO
+	for _, rev := range msg.Revisions {
+		revisions = append(revisions, ChatRevisionDTO{Message: rev.Message, EditedAt: rev.EditedAt})
+	}
+
+	return ChatMessageDTO{
+		ID:          msg.ID,
+		SenderID:    msg.SenderID,
+		Message:     msg.Message,
+		Attachments: msg.Attachments,
+		Internal:    msg.Internal,
+		CreatedAt:   msg.CreatedAt,
+		UpdatedAt:   msg.UpdatedAt,
+		Edited:      len(msg.Revisions) > 0,
+		Revisions:   revisions,
+	}
+}
+
+// ChatMessagesQuery pages through the chat of a ticket. Without a cursor the latest messages are returned.
+type ChatMessagesQuery struct {
+	Before string `form:"before" binding:"omitempty,uuid"` // only messages older than this message
+	After  string `form:"after" binding:"omitempty,uuid"`  // only messages newer than this message
+	Limit  int    `form:"limit"`                           // page size, bounded by the ticket paging config
+}
+
+// ChatMessagesPage is a page of the chat of a ticket, oldest message first
+type ChatMessagesPage struct {
+	Items   []ChatMessageDTO `json:"items"`
+	HasMore bool             `json:"hasMore"` // more messages exist in the paging direction: older without a cursor or with before, newer with after
 }
 
 type ChatMessageResponseID struct {
@@ -24,6 +79,19 @@ type ChatMessageCreateRequest struct {
 	SenderID    int64    `json:"senderId"`
 	Message     string   `json:"message"`
 	Attachments []string `json:"attachments,omitempty"`
+	TemplateID  *int64   `json:"templateId,omitempty"` // staff only, posts the rendered canned response instead of message
+	Internal    bool     `json:"-"`                    // set by the handler for staff notes
+}
+
+// ChatMessageEditRequest replaces the text of a chat message
+type ChatMessageEditRequest struct {
+	MessageID string `json:"messageId" binding:"required,uuid"`
+	Message   string `json:"message" binding:"required"`
+}
+
+// ChatMessageDeleteRequest deletes a chat message
+type ChatMessageDeleteRequest struct {
+	MessageID string `json:"messageId" binding:"required,uuid"`
 }
 
 func (r *ChatMessageCreateRequest) ToModel() *model.ChatMessage {
@@ -33,6 +101,7 @@ func (r *ChatMessageCreateRequest) ToModel() *model.ChatMessage {
 		SenderID:    r.SenderID,
 		Message:     r.Message,
 		Attachments: r.Attachments,
+		Internal:    r.Internal,
 		CreatedAt:   now,
 		UpdatedAt:   now,
 	}
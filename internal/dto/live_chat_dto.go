@@ -0,0 +1,31 @@
+package dto
+
+import "ticket-api/internal/errx"
+
+// Live chat frame types sent by clients, server frames reuse the ticket stream event types
+const (
+	LiveChatFrameMessage = "message" // client sends a chat message
+	LiveChatFrameTyping  = "typing"  // client started or stopped typing
+	LiveChatFrameAck     = "ack"     // server confirms a message was saved
+	LiveChatFrameError   = "error"   // server rejects a frame
+	LiveChatFramePing    = "ping"    // server keep-alive
+)
+
+// LiveChatClientFrame is a frame sent by a client over the live chat WebSocket
+type LiveChatClientFrame struct {
+	Type        string   `json:"type"`
+	ClientID    string   `json:"clientId,omitempty"`    // chosen by the client and echoed in the ack of a message
+	Message     string   `json:"message,omitempty"`     // set for message frames
+	Attachments []string `json:"attachments,omitempty"` // set for message frames
+	TemplateID  *int64   `json:"templateId,omitempty"`  // staff only, set for message frames posting a canned response
+	Internal    bool     `json:"internal,omitempty"`    // staff only, the message or typing is an internal note
+	Typing      bool     `json:"typing,omitempty"`      // set for typing frames
+}
+
+// LiveChatAckFrame answers a client frame with the saved message or the error that rejected it
+type LiveChatAckFrame struct {
+	Type     string          `json:"type"`
+	ClientID string          `json:"clientId,omitempty"`
+	Chat     *ChatMessageDTO `json:"chat,omitempty"`
+	Error    *errx.APIError  `json:"error,omitempty"`
+}
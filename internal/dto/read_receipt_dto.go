@@ -0,0 +1,31 @@
+package dto
+
+import (
+	"ticket-api/internal/model"
+	"time"
+)
+
+// ReadReceiptDTO is the last chat message of a ticket a participant has read
+type ReadReceiptDTO struct {
+	UserID        int64     `json:"userId" bson:"userId"`
+	MessageID     string    `json:"messageId" bson:"messageId"`
+	MessageSentAt time.Time `json:"messageSentAt" bson:"messageSentAt"`
+	ReadAt        time.Time `json:"readAt" bson:"readAt"`
+	Internal      bool      `json:"-" bson:"internal"` // points at an internal note, stripped for the requester
+}
+
+// MarkTicketReadRequest marks the chat of a ticket as read up to a message
+type MarkTicketReadRequest struct {
+	TicketID  string `json:"ticketId" binding:"required,uuid"`
+	MessageID string `json:"messageId,omitempty" binding:"omitempty,uuid"` // defaults to the latest message the user can see
+}
+
+func ToReadReceiptDTO(receipt *model.ReadReceipt) ReadReceiptDTO {
+	return ReadReceiptDTO{
+		UserID:        receipt.UserID,
+		MessageID:     receipt.MessageID,
+		MessageSentAt: receipt.MessageSentAt,
+		ReadAt:        receipt.ReadAt,
+		Internal:      receipt.Internal,
+	}
+}
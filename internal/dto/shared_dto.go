@@ -6,11 +6,13 @@ type IDResponse[T int64 | int32 | string] struct {
 
 type IDRequest[T int64 | int32 | string] = IDResponse[T]
 
-// PagingResponse is a generic paged response
+// PagingResponse is a generic paged response.
+// In cursor mode only Items, PageSize and NextCursor are set.
 type PagingResponse[T any] struct {
-	Items      []T   `json:"items"`       // paged items
-	Total      int64 `json:"total"`       // total number of items
-	Page       int   `json:"page"`        // current page
-	PageSize   int   `json:"page_size"`   // number of items per page
-	TotalPages int   `json:"total_pages"` // total pages
+	Items      []T    `json:"items"`                 // paged items
+	Total      int64  `json:"total"`                 // total number of items
+	Page       int    `json:"page"`                  // current page
+	PageSize   int    `json:"page_size"`             // number of items per page
+	TotalPages int    `json:"total_pages"`           // total pages
+	NextCursor string `json:"next_cursor,omitempty"` // cursor of the next page, empty on the last page
 }
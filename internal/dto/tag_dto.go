@@ -0,0 +1,58 @@
+package dto
+
+import (
+	"ticket-api/internal/db/tags"
+)
+
+// TagCreateRequest is the payload for adding a tag to the catalog
+type TagCreateRequest struct {
+	Title        string `json:"title" binding:"required,max=50"`
+	Color        string `json:"color,omitempty" binding:"omitempty,hexcolor"` // defaults to gray
+	DepartmentID *int64 `json:"departmentId,omitempty"`                       // nil makes the tag usable in every department
+}
+
+// TagUpdateRequest replaces the fields of a tag
+type TagUpdateRequest struct {
+	ID     int64 `json:"id" binding:"required"`
+	Active bool  `json:"active"` // inactive tags stay on tickets but can no longer be added
+	TagCreateRequest
+}
+
+// TagDTO is a tag of the catalog
+type TagDTO struct {
+	ID           int64  `json:"id"`
+	Title        string `json:"title"`
+	Color        string `json:"color"`
+	DepartmentID *int64 `json:"departmentId,omitempty"`
+	Active       bool   `json:"active"`
+}
+
+// ToTagDTO converts a tags.Tag
+func ToTagDTO(m *tags.Tag) *TagDTO {
+	var departmentID *int64
+	if m.DepartmentID.Valid {
+		departmentID = &m.DepartmentID.Int64
+	}
+
+	return &TagDTO{
+		ID:           m.ID,
+		Title:        m.Title,
+		Color:        m.Color,
+		DepartmentID: departmentID,
+		Active:       m.Status == 1,
+	}
+}
+
+// TicketTagsRequest adds or removes tags on a ticket
+type TicketTagsRequest struct {
+	TicketID string  `json:"ticketId" binding:"required,uuid"`
+	TagIDs   []int64 `json:"tagIds" binding:"required,min=1"`
+}
+
+// TagUsageDTO is the number of tickets carrying a tag
+type TagUsageDTO struct {
+	TagID int64  `json:"tagId"`
+	Title string `json:"title"`
+	Color string `json:"color"`
+	Count int64  `json:"count"`
+}
@@ -0,0 +1,64 @@
+package dto
+
+import (
+	"errors"
+	"ticket-api/internal/errx"
+)
+
+// Bulk ticket actions
+const (
+	TicketBulkActionChangeStatus     = "changeStatus"
+	TicketBulkActionChangeDepartment = "changeDepartment"
+	TicketBulkActionAssign           = "assign"
+	TicketBulkActionTag              = "tag"
+	TicketBulkActionClose            = "close"
+)
+
+// TicketBulkRequest applies one action to many tickets, given by ID or by a filter
+type TicketBulkRequest struct {
+	TicketIDs []string           `json:"ticketIds,omitempty" binding:"omitempty,dive,uuid"`
+	Filter    *TicketQueryParams `json:"filter,omitempty"`
+	Action    string             `json:"action" binding:"required,oneof=changeStatus changeDepartment assign tag close"`
+
+	TicketStatusID int64   `json:"ticketStatusId,omitempty"` // changeStatus
+	DepartmentID   int64   `json:"departmentId,omitempty"`   // changeDepartment, fails tickets whose assignee is in another department
+	AssigneeID     int64   `json:"assigneeId,omitempty"`     // assign, 0 unassigns
+	TagIDs         []int64 `json:"tagIds,omitempty"`         // tag
+}
+
+// Validate checks that the tickets are selected one way and the action has its parameter
+func (r *TicketBulkRequest) Validate() error {
+	if (len(r.TicketIDs) == 0) == (r.Filter == nil) {
+		return errors.New("exactly one of ticketIds and filter is required")
+	}
+
+	switch r.Action {
+	case TicketBulkActionChangeStatus:
+		if r.TicketStatusID == 0 {
+			return errors.New("ticketStatusId is required for changeStatus")
+		}
+	case TicketBulkActionChangeDepartment:
+		if r.DepartmentID == 0 {
+			return errors.New("departmentId is required for changeDepartment")
+		}
+	case TicketBulkActionTag:
+		if len(r.TagIDs) == 0 {
+			return errors.New("tagIds is required for tag")
+		}
+	}
+	return nil
+}
+
+// TicketBulkResult is the outcome of a bulk action for one ticket
+type TicketBulkResult struct {
+	TicketID string         `json:"ticketId"`
+	Success  bool           `json:"success"`
+	Error    *errx.APIError `json:"error,omitempty"`
+}
+
+// TicketBulkResponse lists the outcome of a bulk action per ticket
+type TicketBulkResponse struct {
+	Succeeded int                `json:"succeeded"`
+	Failed    int                `json:"failed"`
+	Results   []TicketBulkResult `json:"results"`
+}
@@ -3,6 +3,10 @@ package dto
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
 	"ticket-api/internal/db/ticket_statuses"
 	"ticket-api/internal/model"
 	"ticket-api/internal/util"
@@ -21,9 +25,15 @@ type TicketCreateRequest struct {
 	TicketTypeID   int64    `json:"ticketTypeID" binding:"required"`
 	DepartmentID   int64    `json:"departmentId" binding:"required"`
 	TicketStatusID int64    `json:"-"`
+	Priority       int64    `json:"-"`
 	Title          string   `json:"title" binding:"required"`
 	Body           string   `json:"body" binding:"required"`
 	Attachments    []string `json:"attachments,omitempty"`
+
+	// values of the custom fields of the ticket type, keyed by field name
+	CustomFields map[string]any `json:"customFields,omitempty"`
+
+	SLA *model.TicketSLA `json:"-"` // set by the handler from the matching SLA policy
 }
 
 // ToModel converts a TicketCreateRequest into a model.Ticket
@@ -34,8 +44,10 @@ func (dto *TicketCreateRequest) ToModel(ctx context.Context, ticketCollection *m
 		return nil, err
 	}
 
+	ticketID := util.GenerateUUID()
 	firstMessage := model.ChatMessage{
 		ID:          util.GenerateUUID(),
+		TicketID:    ticketID,
 		SenderID:    dto.UserID,
 		Message:     dto.Body,
 		Attachments: dto.Attachments,
@@ -44,17 +56,23 @@ func (dto *TicketCreateRequest) ToModel(ctx context.Context, ticketCollection *m
 	}
 
 	return &model.Ticket{
-		ID:              util.GenerateUUID(),
-		TrackCode:       trackCode,
-		UserID:          dto.UserID,
-		TicketTypeID:    dto.TicketTypeID,
-		DepartmentID:    dto.DepartmentID,
-		TicketStatusID:  dto.TicketStatusID,
-		Title:           dto.Title,
-		AttachmentCount: len(dto.Attachments),
-		CreatedAt:       now,
-		UpdatedAt:       now,
-		Chat:            []model.ChatMessage{firstMessage},
+		ID:                ticketID,
+		TrackCode:         trackCode,
+		UserID:            dto.UserID,
+		TicketTypeID:      dto.TicketTypeID,
+		DepartmentID:      dto.DepartmentID,
+		TicketStatusID:    dto.TicketStatusID,
+		Priority:          dto.Priority,
+		CustomFields:      dto.CustomFields,
+		Title:             dto.Title,
+		AttachmentCount:   len(dto.Attachments),
+		CreatedAt:         now,
+		UpdatedAt:         now,
+		Chat:              []model.ChatMessage{firstMessage},
+		LastMessage:       firstMessage.Ref(),
+		LastPublicMessage: firstMessage.Ref(),
+		SLA:               dto.SLA,
+		SearchText:        []string{util.NormalizeSearchText(dto.Title), util.NormalizeSearchText(dto.Body)},
 	}, nil
 }
 
@@ -71,9 +89,68 @@ type TicketResponse struct {
 	DepartmentID   int64            `json:"departmentId" bson:"departmentId"`
 	Title          string           `json:"title" bson:"title"`
 	TicketStatusID int64            `json:"ticketStatusId" bson:"ticketStatusId"`
+	AssigneeID     int64            `json:"assigneeId,omitempty" bson:"assigneeId"`
+	Priority       int64            `json:"priority" bson:"priority"`
+	Tags           []int64          `json:"tags,omitempty" bson:"tags"`
+	CustomFields   map[string]any   `json:"customFields,omitempty" bson:"customFields"`
+	SLA            *TicketSLADTO    `json:"sla,omitempty" bson:"sla"`
 	CreatedAt      time.Time        `json:"createdAt" bson:"createdAt"`
 	UpdatedAt      time.Time        `json:"updatedAt" bson:"updatedAt"`
 	Chat           []ChatMessageDTO `json:"chat" bson:"chat"`
+	ReadReceipts   []ReadReceiptDTO `json:"readReceipts,omitempty" bson:"readReceipts"` // last message read by each participant
+	HasOlderChat   bool             `json:"hasOlderMessages,omitempty" bson:"-"`        // the chat was limited and older messages exist
+	UnreadCount    *int64           `json:"unreadCount,omitempty" bson:"-"`             // messages the current user has not read, set in ticket lists
+	Snippets       []string         `json:"snippets,omitempty" bson:"-"`                // highlighted matches of a search query
+}
+
+// StripInternalMessages removes the staff-only notes from the chat of the ticket, and the
+// read receipts pointing at them. Every response that can reach the requester must go through it.
+func (r *TicketResponse) StripInternalMessages() {
+	r.Chat = slices.DeleteFunc(r.Chat, func(msg ChatMessageDTO) bool { return msg.Internal })
+	r.ReadReceipts = slices.DeleteFunc(r.ReadReceipts, func(receipt ReadReceiptDTO) bool { return receipt.Internal })
+}
+
+// TicketSLADTO represents the service level deadlines of a ticket
+type TicketSLADTO struct {
+	PolicyID           int64      `json:"policyId" bson:"policyId"`
+	FirstResponseDueAt time.Time  `json:"firstResponseDueAt" bson:"firstResponseDueAt"`
+	ResolveDueAt       time.Time  `json:"resolveDueAt" bson:"resolveDueAt"`
+	FirstRespondedAt   *time.Time `json:"firstRespondedAt,omitempty" bson:"firstRespondedAt"`
+	ResolvedAt         *time.Time `json:"resolvedAt,omitempty" bson:"resolvedAt"`
+	PausedAt           *time.Time `json:"pausedAt,omitempty" bson:"pausedAt"`
+	Breached           bool       `json:"slaBreached" bson:"breached"`
+}
+
+// ToTicketSLADTO converts a model.TicketSLA, evaluating breaches at the current time
+func ToTicketSLADTO(sla *model.TicketSLA) *TicketSLADTO {
+	if sla == nil {
+		return nil
+	}
+	return &TicketSLADTO{
+		PolicyID:           sla.PolicyID,
+		FirstResponseDueAt: sla.FirstResponseDueAt,
+		ResolveDueAt:       sla.ResolveDueAt,
+		FirstRespondedAt:   sla.FirstRespondedAt,
+		ResolvedAt:         sla.ResolvedAt,
+		PausedAt:           sla.PausedAt,
+		Breached:           sla.IsBreached(time.Now()),
+	}
+}
+
+// ToModel converts TicketSLADTO into model.TicketSLA
+func (s *TicketSLADTO) ToModel() *model.TicketSLA {
+	if s == nil {
+		return nil
+	}
+	return &model.TicketSLA{
+		PolicyID:           s.PolicyID,
+		FirstResponseDueAt: s.FirstResponseDueAt,
+		ResolveDueAt:       s.ResolveDueAt,
+		FirstRespondedAt:   s.FirstRespondedAt,
+		ResolvedAt:         s.ResolvedAt,
+		PausedAt:           s.PausedAt,
+		Breached:           s.Breached,
+	}
 }
 
 // ToModel converts TicketRaw into model.Ticket
@@ -85,6 +162,7 @@ func (r *TicketResponse) ToModel() *model.Ticket {
 			SenderID:    msg.SenderID,
 			Message:     msg.Message,
 			Attachments: msg.Attachments,
+			Internal:    msg.Internal,
 			CreatedAt:   msg.CreatedAt,
 			UpdatedAt:   msg.UpdatedAt,
 		}
@@ -96,6 +174,11 @@ func (r *TicketResponse) ToModel() *model.Ticket {
 		UserID:         r.UserID,
 		TicketTypeID:   r.TicketTypeID,
 		TicketStatusID: r.TicketStatusID,
+		AssigneeID:     r.AssigneeID,
+		Priority:       r.Priority,
+		Tags:           r.Tags,
+		CustomFields:   r.CustomFields,
+		SLA:            r.SLA.ToModel(),
 		DepartmentID:   r.DepartmentID,
 		Title:          r.Title,
 		CreatedAt:      r.CreatedAt,
@@ -114,9 +197,10 @@ type TicketFullResponse struct {
 	TicketType     string           `json:"ticketType"`
 	DepartmentID   int64            `json:"departmentId"`
 	DepartmentName string           `json:"departmentName"`
-	Priority       int              `json:"priority"`
+	Priority       int64            `json:"priority"`
 	Title          string           `json:"title"`
 	TicketStatus   string           `json:"ticketStatus"`
+	AssigneeID     int64            `json:"assigneeId,omitempty"`
 	CreatedAt      time.Time        `json:"createdAt"`
 	UpdatedAt      time.Time        `json:"updatedAt"`
 	Chat           []ChatMessageDTO `json:"chat"`
@@ -124,15 +208,13 @@ type TicketFullResponse struct {
 
 func ToTicketResponse(ticket *model.Ticket) *TicketResponse {
 	chatDTOs := make([]ChatMessageDTO, len(ticket.Chat))
-	for i, msg := range ticket.Chat {
-		chatDTOs[i] = ChatMessageDTO{
-			ID:          msg.ID,
-			SenderID:    msg.SenderID,
-			Message:     msg.Message,
-			Attachments: msg.Attachments,
-			CreatedAt:   msg.CreatedAt,
-			UpdatedAt:   msg.UpdatedAt,
-		}
+	for i := range ticket.Chat {
+		chatDTOs[i] = ToChatMessageDTO(&ticket.Chat[i])
+	}
+
+	var receipts []ReadReceiptDTO
+	for i := range ticket.ReadReceipts {
+		receipts = append(receipts, ToReadReceiptDTO(&ticket.ReadReceipts[i]))
 	}
 
 	return &TicketResponse{
@@ -143,14 +225,31 @@ func ToTicketResponse(ticket *model.Ticket) *TicketResponse {
 		DepartmentID:   ticket.DepartmentID,
 		Title:          ticket.Title,
 		TicketStatusID: ticket.TicketStatusID,
+		AssigneeID:     ticket.AssigneeID,
+		Priority:       ticket.Priority,
+		Tags:           ticket.Tags,
+		CustomFields:   ticket.CustomFields,
+		SLA:            ToTicketSLADTO(ticket.SLA),
 		CreatedAt:      ticket.CreatedAt,
 		UpdatedAt:      ticket.UpdatedAt,
 		Chat:           chatDTOs,
+		ReadReceipts:   receipts,
 	}
 }
 
 type TicketByIDRequestDTO struct {
 	ID string `json:"id" binding:"required,uuid"` // assuming UUID
+	TicketChatOptions
+}
+
+// TicketChatOptions selects the part of the chat returned with a ticket. Without them the
+// whole chat is returned; long tickets load faster with a limit and "load older" requests.
+type TicketChatOptions struct {
+	MessagesLimit   int    `json:"messagesLimit,omitempty" binding:"omitempty,min=1"`  // only the latest messages
+	BeforeMessageID string `json:"beforeMessageId,omitempty" binding:"omitempty,uuid"` // only messages older than this message
+
+	PublicOnly bool `json:"-"` // leave out internal notes, set for requesters
+	SkipChat   bool `json:"-"` // load the ticket without its chat
 }
 
 type TicketCreateResponse struct {
@@ -161,21 +260,123 @@ type TicketCreateResponse struct {
 type TicketByTrackCodeRequestDTO struct {
 	TrackCode string `json:"trackCode" binding:"required"`
 	Username  string `json:"username" binding:"required"`
+	TicketChatOptions
 }
 
 type TicketQueryParams struct {
 	Page     int `json:"page,omitempty"`     // page number
 	PageSize int `json:"pageSize,omitempty"` // items per page
 
+	// Cursor switches to keyset paging: send "" for the first page, then the
+	// next_cursor of the previous response. Page is ignored in this mode.
+	Cursor *string `json:"cursor,omitempty"`
+
 	StatusID     int64 `json:"ticketStatusId,omitempty"` // optional filter
 	UserID       int64 `json:"userId,omitempty"`         // optional filter
 	DepartmentID int64 `json:"departmentId,omitempty"`
 	TicketTypeID int64 `json:"ticketTypeId,omitempty"`
+	AssigneeID   int64 `json:"assigneeId,omitempty"`
+
+	// multi-value filters, combined with the single value filters above
+	StatusIDs     []int64 `json:"statusIds,omitempty"`
+	UserIDs       []int64 `json:"userIds,omitempty"`
+	DepartmentIDs []int64 `json:"departmentIds,omitempty"`
+	TicketTypeIDs []int64 `json:"ticketTypeIds,omitempty"`
+
+	// exclusion filters
+	ExcludeStatusIDs     []int64 `json:"excludeStatusIds,omitempty"`
+	ExcludeUserIDs       []int64 `json:"excludeUserIds,omitempty"`
+	ExcludeDepartmentIDs []int64 `json:"excludeDepartmentIds,omitempty"`
+	ExcludeTicketTypeIDs []int64 `json:"excludeTicketTypeIds,omitempty"`
+
+	// date ranges, both bounds inclusive
+	CreatedFrom *time.Time `json:"createdFrom,omitempty"`
+	CreatedTo   *time.Time `json:"createdTo,omitempty"`
+	UpdatedFrom *time.Time `json:"updatedFrom,omitempty"`
+	UpdatedTo   *time.Time `json:"updatedTo,omitempty"`
+
+	HasAttachments *bool `json:"hasAttachments,omitempty"` // true: only tickets with attachments, false: only without
+
+	Tags    []int64 `json:"tags,omitempty"`    // only tickets carrying all of these tags
+	AnyTags []int64 `json:"anyTags,omitempty"` // only tickets carrying at least one of these tags
+
+	CustomFields map[string]CustomFieldFilter `json:"customFields,omitempty"` // conditions on custom field values, keyed by field name
 
-	OrderBy  string `json:"orderBy,omitempty"`  // field to order by
+	MyQueue    bool `json:"myQueue,omitempty"`    // only tickets assigned to the current user
+	Unassigned bool `json:"unassigned,omitempty"` // only tickets nobody is handling yet
+	UnreadByMe bool `json:"unreadByMe,omitempty"` // only tickets with messages the current user has not read
+
+	// The current user, set by the handler. Unread counts are only computed when ReaderID is set,
+	// internal notes only count for staff.
+	ReaderID      int64 `json:"-"`
+	ReaderIsStaff bool  `json:"-"`
+
+	SLABreached bool       `json:"slaBreached,omitempty"` // only tickets that missed an SLA deadline
+	DueBefore   *time.Time `json:"dueBefore,omitempty"`   // only tickets with an open SLA deadline before this time
+
+	Query string `json:"query,omitempty"` // full-text search in the title and chat messages
+
+	OrderBy  string `json:"orderBy,omitempty"`  // field to order by, or "relevance" when searching
 	OrderDir string `json:"orderDir,omitempty"` // asc or desc
 }
 
+// Validate checks that the date ranges of the query are well formed. Stored views are checked
+// with it too, so it must not depend on the user running the query.
+func (q *TicketQueryParams) Validate() error {
+	if q.CreatedFrom != nil && q.CreatedTo != nil && q.CreatedFrom.After(*q.CreatedTo) {
+		return errors.New("createdFrom must not be after createdTo")
+	}
+	if q.UpdatedFrom != nil && q.UpdatedTo != nil && q.UpdatedFrom.After(*q.UpdatedTo) {
+		return errors.New("updatedFrom must not be after updatedTo")
+	}
+	for name, f := range q.CustomFields {
+		if !model.CustomFieldNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid custom field name %q", name)
+		}
+		if err := f.validate(); err != nil {
+			return fmt.Errorf("custom field %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// ValidateRun checks the query like Validate, and that the handler set the reader the query
+// needs to run
+func (q *TicketQueryParams) ValidateRun() error {
+	if err := q.Validate(); err != nil {
+		return err
+	}
+	if q.UnreadByMe && q.ReaderID == 0 {
+		return errors.New("unreadByMe needs a logged in user")
+	}
+	return nil
+}
+
+// CustomFieldFilter matches a custom field value. All set conditions must hold.
+// Dates are compared as YYYY-MM-DD strings.
+type CustomFieldFilter struct {
+	Eq   any   `json:"eq,omitempty"`   // equal to
+	In   []any `json:"in,omitempty"`   // equal to one of
+	From any   `json:"from,omitempty"` // greater than or equal to
+	To   any   `json:"to,omitempty"`   // less than or equal to
+}
+
+// validate rejects empty filters and non scalar values, which Mongo would read as operators
+func (f *CustomFieldFilter) validate() error {
+	if f.Eq == nil && len(f.In) == 0 && f.From == nil && f.To == nil {
+		return errors.New("filter has no condition")
+	}
+	values := append([]any{f.Eq, f.From, f.To}, f.In...)
+	for _, v := range values {
+		switch v.(type) {
+		case nil, string, float64, bool:
+		default:
+			return fmt.Errorf("unsupported value %v", v)
+		}
+	}
+	return nil
+}
+
 type TicketTypeDto struct {
 	ID          int64   `json:"id"`
 	Title       string  `json:"title"`
@@ -216,6 +417,7 @@ type TicketStatusDTO struct {
 	ID          int64   `json:"id"`
 	Title       string  `json:"title"`
 	Description *string `json:"description,omitempty"`
+	Kind        string  `json:"kind"`
 }
 
 func ToTicketStatusDTO(m *ticket_statuses.TicketStatus) *TicketStatusDTO {
@@ -228,6 +430,7 @@ func ToTicketStatusDTO(m *ticket_statuses.TicketStatus) *TicketStatusDTO {
 		ID:          m.ID,
 		Title:       m.Title,
 		Description: description,
+		Kind:        m.Kind,
 	}
 }
 
@@ -245,9 +448,108 @@ func (dt *TicketStatusDTO) ToModel() *ticket_statuses.TicketStatus {
 		Description: nullDesc,
 		Status:      1,
 		Deleted:     0,
+		Kind:        dt.Kind,
 	}
 }
 
+// TicketAssignRequest assigns (or reassigns) a ticket to an agent
+type TicketAssignRequest struct {
+	TicketID   string `json:"ticketId" binding:"required,uuid"`
+	AssigneeID int64  `json:"assigneeId" binding:"required"`
+}
+
+// TicketChangeStatusRequest moves a ticket to another status of the workflow
+type TicketChangeStatusRequest struct {
+	TicketID       string `json:"ticketId" binding:"required,uuid"`
+	TicketStatusID int64  `json:"ticketStatusId" binding:"required"`
+}
+
+// TicketSetPriorityRequest overrides the priority of a ticket
+type TicketSetPriorityRequest struct {
+	TicketID string `json:"ticketId" binding:"required,uuid"`
+	Priority *int64 `json:"priority" binding:"required,min=0"`
+}
+
+// TicketFileLinkRequest asks for a download link of a ticket attachment. Anonymous requesters
+// give the track code and username of the ticket.
+type TicketFileLinkRequest struct {
+	ID        string `json:"id" binding:"required,uuid"`
+	TrackCode string `json:"trackCode"`
+	Username  string `json:"username"`
+}
+
 type TicketDownloadLink struct {
 	Url string `json:"url"`
 }
+
+// TicketTypeFieldRequest is the payload for adding a custom field to a ticket type
+type TicketTypeFieldRequest struct {
+	TicketTypeID int64    `json:"ticketTypeId" binding:"required"`
+	Name         string   `json:"name" binding:"required"` // key of the value in customFields
+	Label        string   `json:"label" binding:"required,max=100"`
+	Type         string   `json:"type" binding:"required,oneof=text number enum date"`
+	Required     bool     `json:"required"`
+	Pattern      string   `json:"pattern,omitempty"` // regular expression text values must match
+	Options      []string `json:"options,omitempty"` // allowed values of an enum field
+	SortOrder    int64    `json:"sortOrder"`
+}
+
+// Validate checks the parts of the definition binding tags cannot express
+func (r *TicketTypeFieldRequest) Validate() error {
+	if !model.CustomFieldNamePattern.MatchString(r.Name) {
+		return fmt.Errorf("invalid field name %q", r.Name)
+	}
+	if r.Type == model.CustomFieldEnum && len(r.Options) == 0 {
+		return errors.New("enum fields need options")
+	}
+	if r.Type != model.CustomFieldEnum && len(r.Options) > 0 {
+		return errors.New("only enum fields have options")
+	}
+	if r.Pattern != "" {
+		if r.Type != model.CustomFieldText {
+			return errors.New("only text fields have a pattern")
+		}
+		if _, err := regexp.Compile(r.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+	}
+	return nil
+}
+
+// TicketTypeFieldDTO is a custom field of a ticket type
+type TicketTypeFieldDTO struct {
+	ID           int64    `json:"id"`
+	TicketTypeID int64    `json:"ticketTypeId"`
+	Name         string   `json:"name"`
+	Label        string   `json:"label"`
+	Type         string   `json:"type"`
+	Required     bool     `json:"required"`
+	Pattern      *string  `json:"pattern,omitempty"`
+	Options      []string `json:"options,omitempty"`
+	SortOrder    int64    `json:"sortOrder"`
+}
+
+// ToTicketTypeFieldDTO converts a model.TicketTypeField
+func ToTicketTypeFieldDTO(m *model.TicketTypeField) (*TicketTypeFieldDTO, error) {
+	options, err := model.CustomFieldOptions(m)
+	if err != nil {
+		return nil, err
+	}
+
+	var pattern *string
+	if m.Pattern.Valid {
+		pattern = &m.Pattern.String
+	}
+
+	return &TicketTypeFieldDTO{
+		ID:           m.ID,
+		TicketTypeID: m.TicketTypeID,
+		Name:         m.Name,
+		Label:        m.Label,
+		Type:         m.FieldType,
+		Required:     m.Required != 0,
+		Pattern:      pattern,
+		Options:      options,
+		SortOrder:    m.SortOrder,
+	}, nil
+}
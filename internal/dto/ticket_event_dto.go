@@ -0,0 +1,31 @@
+package dto
+
+import (
+	"ticket-api/internal/model"
+	"time"
+)
+
+// TicketEventDTO represents a ticket history entry in responses
+type TicketEventDTO struct {
+	ID        string    `json:"id"`
+	TicketID  string    `json:"ticketId"`
+	ActorID   int64     `json:"actorId"`
+	Action    string    `json:"action"`
+	Field     string    `json:"field,omitempty"`
+	OldValue  any       `json:"oldValue,omitempty"`
+	NewValue  any       `json:"newValue,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func ToTicketEventDTO(e *model.TicketEvent) *TicketEventDTO {
+	return &TicketEventDTO{
+		ID:        e.ID,
+		TicketID:  e.TicketID,
+		ActorID:   e.ActorID,
+		Action:    e.Action,
+		Field:     e.Field,
+		OldValue:  e.OldValue,
+		NewValue:  e.NewValue,
+		CreatedAt: e.CreatedAt,
+	}
+}
@@ -0,0 +1,35 @@
+package dto
+
+import "time"
+
+// Ticket stream event types
+const (
+	TicketStreamEventChat        = "chat"        // a chat message was added
+	TicketStreamEventChatEdited  = "chatEdited"  // a chat message was edited
+	TicketStreamEventChatDeleted = "chatDeleted" // a chat message was deleted
+	TicketStreamEventStatus      = "status"      // the ticket moved to another status
+	TicketStreamEventAssignment  = "assignment"  // the ticket was assigned or unassigned
+	TicketStreamEventTyping      = "typing"      // a live chat user started or stopped typing
+	TicketStreamEventPresence    = "presence"    // a user opened or left the live chat of the ticket
+	TicketStreamEventRead        = "read"        // a participant read the chat up to a message
+)
+
+// TicketStreamEvent is a live update of a ticket pushed to its Server-Sent Events stream and live chat
+type TicketStreamEvent struct {
+	Type           string          `json:"type"`
+	TicketID       string          `json:"ticketId"`
+	ActorID        int64           `json:"actorId,omitempty"`
+	Chat           *ChatMessageDTO `json:"chat,omitempty"`           // set for chat events
+	TicketStatusID int64           `json:"ticketStatusId,omitempty"` // set for status events
+	AssigneeID     *int64          `json:"assigneeId,omitempty"`     // set for assignment events, 0 when unassigned
+	Typing         *bool           `json:"typing,omitempty"`         // set for typing events
+	Viewers        []int64         `json:"viewers,omitempty"`        // set for presence events, users in the live chat
+	Receipt        *ReadReceiptDTO `json:"receipt,omitempty"`        // set for read events
+	Internal       bool            `json:"internal,omitempty"`       // set for typing and read events of an internal note
+	CreatedAt      time.Time       `json:"createdAt"`
+}
+
+// IsInternal reports whether the event concerns an internal note the requester must not see
+func (e *TicketStreamEvent) IsInternal() bool {
+	return e.Internal || (e.Chat != nil && e.Chat.Internal)
+}
@@ -0,0 +1,63 @@
+package dto
+
+import (
+	"encoding/json"
+	"ticket-api/internal/db/ticket_views"
+)
+
+// TicketViewRequest is the payload for saving a ticket view
+type TicketViewRequest struct {
+	Title  string            `json:"title" binding:"required,max=100"`
+	Query  TicketQueryParams `json:"query"`
+	Shared bool              `json:"shared"` // visible to every user of the owner's department
+}
+
+// TicketViewUpdateRequest replaces the title, query and sharing of a view
+type TicketViewUpdateRequest struct {
+	ID int64 `json:"id" binding:"required"`
+	TicketViewRequest
+}
+
+// TicketViewRunRequest runs a saved view with the given paging
+type TicketViewRunRequest struct {
+	ID       int64   `json:"id" binding:"required"`
+	Page     int     `json:"page,omitempty"`
+	PageSize int     `json:"pageSize,omitempty"`
+	Cursor   *string `json:"cursor,omitempty"`
+}
+
+// TicketViewDTO is a saved ticket view
+type TicketViewDTO struct {
+	ID           int64             `json:"id"`
+	UserID       int64             `json:"userId"`
+	DepartmentID int64             `json:"departmentId"`
+	Title        string            `json:"title"`
+	Query        TicketQueryParams `json:"query"`
+	Shared       bool              `json:"shared"`
+	CreatedAt    string            `json:"createdAt"`
+	UpdatedAt    string            `json:"updatedAt"`
+}
+
+// ToTicketViewDTO converts a ticket_views.TicketView, decoding its stored query
+func ToTicketViewDTO(m *ticket_views.TicketView) (*TicketViewDTO, error) {
+	var query TicketQueryParams
+	if err := json.Unmarshal([]byte(m.Query), &query); err != nil {
+		return nil, err
+	}
+
+	return &TicketViewDTO{
+		ID:           m.ID,
+		UserID:       m.UserID,
+		DepartmentID: m.DepartmentID,
+		Title:        m.Title,
+		Query:        query,
+		Shared:       m.Shared != 0,
+		CreatedAt:    m.CreatedAt,
+		UpdatedAt:    m.UpdatedAt,
+	}, nil
+}
+
+// IsVisibleTo reports whether a user of the given department may see and run the view
+func (v *TicketViewDTO) IsVisibleTo(userID int64, departmentID int64) bool {
+	return v.UserID == userID || (v.Shared && v.DepartmentID == departmentID)
+}
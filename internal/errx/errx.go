@@ -43,6 +43,25 @@ const (
 	ErrMaxFileSizeExceeded
 	ErrMaxTicketFilesExceeded
 	ErrRequestBodyTooLarge
+	ErrAssigneeNotInDepartment
+	ErrInvalidStatusTransition
+	ErrStatusTransitionForbidden
+	ErrStaffOnly
+	ErrInvalidTicketFilter
+	ErrTicketViewNotFound
+	ErrTicketConcurrentUpdate
+	ErrBulkLimitExceeded
+	ErrTagNotFound
+	ErrTagNotInDepartment
+	ErrInvalidCustomField
+	ErrTicketTypeFieldNotFound
+	ErrChatMessageNotFound
+	ErrChatMessageNotOwned
+	ErrChatEditWindowExpired
+	ErrCannedResponseNotFound
+	ErrCannedResponseNotInScope
+	ErrFileContentMismatch
+	ErrTempFileNotOwned
 )
 
 //
@@ -104,30 +123,49 @@ var debugMode = func() bool {
 func NewRegistry(db *sql.DB) *Registry {
 	r := &Registry{
 		defs: map[ErrorCode]ErrorDef{
-			ErrInternalServerError:      {"خطای داخلی سرور", http.StatusInternalServerError},
-			ErrTicketNotFound:           {"تیکت پیدا نشد", http.StatusNotFound},
-			ErrUnauthorized:             {"دسترسی غیرمجاز", http.StatusUnauthorized},
-			ErrInvalidInput:             {"داده ورودی نامعتبر است", http.StatusBadRequest},
-			ErrDuplicate:                {"رکورد تکراری است", http.StatusConflict},
-			ErrBadRequest:               {"درخواست نامعتبر", http.StatusBadRequest},
-			ErrUserNotFound:             {"کاربر پیدا نشد", http.StatusNotFound},
-			ErrTicketTypeNotFound:       {"نوع تیکت پیدا نشد.", http.StatusNotFound},
-			ErrDepartmentNotFound:       {"دپارتمان مورد نظر پیدا نشد.", http.StatusNotFound},
-			ErrUserDuplicate:            {"کاربر با این مشخصات قبلاً ثبت شده است", http.StatusConflict},
-			ErrInvalidCredentials:       {"نام کاربری یا رمز عبور اشتباه است", http.StatusUnauthorized},
-			ErrWeakJWTSecret:            {"کلید JWT بسیار کوتاه یا ناامن است", http.StatusInternalServerError},
-			ErrIncorrectCaptcha:         {"کد امنیتی نادرست است", http.StatusUnauthorized},
-			ErrExpiredCaptcha:           {"کد امنیتی منقضی شده است", http.StatusUnauthorized},
-			ErrTicketStatusNotFound:     {"وضعیت تیکت یافت نشد", http.StatusNotFound},
-			ErrTooManyRequest:           {"تعداد درخواست‌ها بیش از حد مجاز است", http.StatusTooManyRequests},
-			ErrApiKeyNotFound:           {"API KEY نامعتبر است", http.StatusUnauthorized},
-			ErrServiceUnavailable:       {"سرویس در دسترس نیست", http.StatusServiceUnavailable},
-			ErrMaxTicketFilesExceeded:   {"تعداد فایل‌های ضمیمه بیش از حد مجاز است", http.StatusBadRequest},
-			ErrFileNotFound:             {"فایل پیدا نشد", http.StatusNotFound},
-			ErrLinkExpired:              {"لینک دانلود منقضی شده است", http.StatusGone},
-			ErrUnsupportedFileExtension: {"فرمت فایل پشتیبانی نمی‌شود", http.StatusBadRequest},
-			ErrMaxFileSizeExceeded:      {"حجم فایل از حد مجاز بیشتر است", http.StatusRequestEntityTooLarge},
-			ErrRequestBodyTooLarge:      {"حجم بدنه درخواست بیش از حد مجاز است", http.StatusRequestEntityTooLarge},
+			ErrInternalServerError:       {"خطای داخلی سرور", http.StatusInternalServerError},
+			ErrTicketNotFound:            {"تیکت پیدا نشد", http.StatusNotFound},
+			ErrUnauthorized:              {"دسترسی غیرمجاز", http.StatusUnauthorized},
+			ErrInvalidInput:              {"داده ورودی نامعتبر است", http.StatusBadRequest},
+			ErrDuplicate:                 {"رکورد تکراری است", http.StatusConflict},
+			ErrBadRequest:                {"درخواست نامعتبر", http.StatusBadRequest},
+			ErrUserNotFound:              {"کاربر پیدا نشد", http.StatusNotFound},
+			ErrTicketTypeNotFound:        {"نوع تیکت پیدا نشد.", http.StatusNotFound},
+			ErrDepartmentNotFound:        {"دپارتمان مورد نظر پیدا نشد.", http.StatusNotFound},
+			ErrUserDuplicate:             {"کاربر با این مشخصات قبلاً ثبت شده است", http.StatusConflict},
+			ErrInvalidCredentials:        {"نام کاربری یا رمز عبور اشتباه است", http.StatusUnauthorized},
+			ErrWeakJWTSecret:             {"کلید JWT بسیار کوتاه یا ناامن است", http.StatusInternalServerError},
+			ErrIncorrectCaptcha:          {"کد امنیتی نادرست است", http.StatusUnauthorized},
+			ErrExpiredCaptcha:            {"کد امنیتی منقضی شده است", http.StatusUnauthorized},
+			ErrTicketStatusNotFound:      {"وضعیت تیکت یافت نشد", http.StatusNotFound},
+			ErrTooManyRequest:            {"تعداد درخواست‌ها بیش از حد مجاز است", http.StatusTooManyRequests},
+			ErrApiKeyNotFound:            {"API KEY نامعتبر است", http.StatusUnauthorized},
+			ErrServiceUnavailable:        {"سرویس در دسترس نیست", http.StatusServiceUnavailable},
+			ErrMaxTicketFilesExceeded:    {"تعداد فایل‌های ضمیمه بیش از حد مجاز است", http.StatusBadRequest},
+			ErrFileNotFound:              {"فایل پیدا نشد", http.StatusNotFound},
+			ErrLinkExpired:               {"لینک دانلود منقضی شده است", http.StatusGone},
+			ErrUnsupportedFileExtension:  {"فرمت فایل پشتیبانی نمی‌شود", http.StatusBadRequest},
+			ErrMaxFileSizeExceeded:       {"حجم فایل از حد مجاز بیشتر است", http.StatusRequestEntityTooLarge},
+			ErrRequestBodyTooLarge:       {"حجم بدنه درخواست بیش از حد مجاز است", http.StatusRequestEntityTooLarge},
+			ErrAssigneeNotInDepartment:   {"کارشناس انتخاب شده عضو دپارتمان این تیکت نیست", http.StatusUnprocessableEntity},
+			ErrInvalidStatusTransition:   {"تغییر وضعیت تیکت به این وضعیت مجاز نیست", http.StatusConflict},
+			ErrStatusTransitionForbidden: {"شما اجازه این تغییر وضعیت را ندارید", http.StatusForbidden},
+			ErrStaffOnly:                 {"این عملیات فقط برای کارشناسان مجاز است", http.StatusForbidden},
+			ErrInvalidTicketFilter:       {"فیلتر جستجوی تیکت نامعتبر است", http.StatusBadRequest},
+			ErrTicketViewNotFound:        {"نمای ذخیره شده پیدا نشد", http.StatusNotFound},
+			ErrTicketConcurrentUpdate:    {"تیکت همزمان توسط درخواست دیگری تغییر کرد", http.StatusConflict},
+			ErrBulkLimitExceeded:         {"تعداد تیکت‌ها بیش از حد مجاز عملیات گروهی است", http.StatusBadRequest},
+			ErrTagNotFound:               {"برچسب پیدا نشد", http.StatusNotFound},
+			ErrTagNotInDepartment:        {"برچسب انتخاب شده برای دپارتمان این تیکت تعریف نشده است", http.StatusUnprocessableEntity},
+			ErrInvalidCustomField:        {"مقدار فیلدهای سفارشی تیکت نامعتبر است", http.StatusBadRequest},
+			ErrTicketTypeFieldNotFound:   {"فیلد سفارشی پیدا نشد", http.StatusNotFound},
+			ErrChatMessageNotFound:       {"پیام پیدا نشد", http.StatusNotFound},
+			ErrChatMessageNotOwned:       {"فقط فرستنده پیام می‌تواند آن را ویرایش یا حذف کند", http.StatusForbidden},
+			ErrChatEditWindowExpired:     {"مهلت ویرایش یا حذف این پیام به پایان رسیده است", http.StatusForbidden},
+			ErrCannedResponseNotFound:    {"پاسخ آماده پیدا نشد", http.StatusNotFound},
+			ErrCannedResponseNotInScope:  {"پاسخ آماده برای دپارتمان یا نوع این تیکت تعریف نشده است", http.StatusUnprocessableEntity},
+			ErrFileContentMismatch:       {"محتوای فایل با پسوند آن مطابقت ندارد", http.StatusUnsupportedMediaType},
+			ErrTempFileNotOwned:          {"فایل ضمیمه متعلق به شما نیست یا مهلت استفاده از آن تمام شده است", http.StatusForbidden},
 		},
 		db: db,
 	}
@@ -1,34 +1,51 @@
 package handler
 
 import (
+	"context"
+	"errors"
+	"log"
+	"ticket-api/internal/dto"
 	"ticket-api/internal/errx"
 	"ticket-api/internal/repository"
 	"ticket-api/internal/services"
+	"ticket-api/internal/services/cookie"
+	"ticket-api/internal/services/storage"
+	"ticket-api/internal/services/stream"
+	"ticket-api/internal/services/token"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 type AppHandlers struct {
-	Version    *VersionHandler
-	Ticket     *TicketHandler
-	Chat       *ChatHandler
-	User       *UserHandler
-	Auth       *AuthHandler
-	Captcha    *CaptchaHandler
-	Department *DepartmentHandler
-	File       *FileHandler
+	Version        *VersionHandler
+	Ticket         *TicketHandler
+	TicketView     *TicketViewHandler
+	TicketStream   *TicketStreamHandler
+	Tag            *TagHandler
+	CannedResponse *CannedResponseHandler
+	Chat           *ChatHandler
+	User           *UserHandler
+	Auth           *AuthHandler
+	Captcha        *CaptchaHandler
+	Department     *DepartmentHandler
+	File           *FileHandler
 }
 
 func NewAppHandlers(repos *repository.AppRepositories, services *services.AppServices) *AppHandlers {
 	return &AppHandlers{
-		Version:    NewVersionHandler(repos.Version),
-		Ticket:     NewTicketHandler(repos.Ticket, repos.TicketTypes, repos.TicketPriorities, repos.TicketStatus, repos.Users, repos.Departments),
-		Chat:       NewChatHandler(repos.Ticket, repos.ChatRepository),
-		User:       NewUserHandler(repos.Users),
-		Auth:       NewAuthHandler(repos.Users, services.Token),
-		Captcha:    NewCaptchaHandler(services.Captcha, services.Token),
-		Department: NewDepartmentHandler(repos.Departments),
-		File:       NewFileHandler(services.FileStorage),
+		Version:        NewVersionHandler(repos.Version),
+		Ticket:         NewTicketHandler(repos.Ticket, repos.TicketTypes, repos.TicketTypeFields, repos.TicketPriorities, repos.TicketStatus, repos.Users, repos.Departments, repos.RolesRelations, repos.TicketHistory, repos.SLAPolicies, repos.Tags, services.Stream, services.Token),
+		TicketView:     NewTicketViewHandler(repos.TicketViews, repos.Ticket, repos.Users, repos.RolesRelations),
+		TicketStream:   NewTicketStreamHandler(repos.Ticket, repos.Users, repos.RolesRelations, services.Stream, services.Token),
+		Tag:            NewTagHandler(repos.Tags, repos.Ticket, repos.RolesRelations),
+		CannedResponse: NewCannedResponseHandler(repos.CannedResponses, repos.RolesRelations),
+		Chat:           NewChatHandler(repos.Ticket, repos.ChatRepository, repos.RolesRelations, repos.Users, repos.Departments, repos.CannedResponses, services.Stream, services.Token),
+		User:           NewUserHandler(repos.Users),
+		Auth:           NewAuthHandler(repos.Users, services.Token),
+		Captcha:        NewCaptchaHandler(services.Captcha, services.Token),
+		Department:     NewDepartmentHandler(repos.Departments),
+		File:           NewFileHandler(services.FileStorage, repos.Ticket, repos.ChatRepository, repos.Users, repos.RolesRelations, services.Token),
 	}
 }
 
@@ -41,3 +58,101 @@ func bindJSON[T any](c *gin.Context, req *T) bool {
 	}
 	return true
 }
+
+// authClaims returns the auth claims stored by AuthorizationMiddleware
+func authClaims(c *gin.Context) (*token.AuthClaims, *errx.APIError) {
+	value, exists := c.Get("user")
+	claims, ok := value.(*token.AuthClaims)
+	if !exists || !ok {
+		return nil, errx.Respond(errx.ErrUnauthorized, errors.New("auth claims not found in context"))
+	}
+	return claims, nil
+}
+
+// requestClaims returns the auth claims of the request: those stored by AuthorizationMiddleware,
+// or those of the auth cookie on routes open to anonymous requesters. Returns nil for anonymous
+// requesters.
+func requestClaims(c *gin.Context, tokenService *token.TokenService) *token.AuthClaims {
+	if claims, err := authClaims(c); err == nil {
+		return claims
+	}
+	authToken, err := cookie.NewAuthCookieService().Get(c)
+	if err != nil {
+		return nil
+	}
+	claims, apiErr := tokenService.ParseAuthToken(authToken)
+	if apiErr != nil {
+		return nil
+	}
+	return claims
+}
+
+// requireStaff responds with ErrStaffOnly and returns false if the user is not staff
+func requireStaff(c *gin.Context, rolesRelationRepo *repository.RolesRelationsRepository, userID int64) bool {
+	isStaff, err := rolesRelationRepo.IsStaff(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return false
+	}
+	if !isStaff {
+		apiErr := errx.Respond(errx.ErrStaffOnly, errors.New("user has no staff role"))
+		c.JSON(apiErr.HTTPStatus, apiErr)
+		return false
+	}
+	return true
+}
+
+// requireStaffClaims returns the auth claims of the signed-in user of the request. It responds
+// with the error and returns false if there are none or the user is not staff.
+func requireStaffClaims(c *gin.Context, rolesRelationRepo *repository.RolesRelationsRepository) (*token.AuthClaims, bool) {
+	claims, err := authClaims(c)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return nil, false
+	}
+	return claims, requireStaff(c, rolesRelationRepo, claims.UserID)
+}
+
+// uploaderOf identifies the client of the request for temp uploads: the user of the auth
+// claims if any, the captcha cookie and the client IP. CaptchaMiddleware has checked the
+// captcha token on routes that need it.
+func uploaderOf(c *gin.Context) storage.Uploader {
+	uploader := storage.Uploader{IP: c.ClientIP()}
+	if claims, err := authClaims(c); err == nil {
+		uploader.UserID = claims.UserID
+	}
+	if captchaToken, err := cookie.NewCaptchaCookieService().Get(c); err == nil {
+		uploader.CaptchaToken = captchaToken
+	}
+	return uploader
+}
+
+// canSeeInternalMessages reports whether the authenticated user is staff and may read internal notes.
+// Requests without auth claims never may.
+func canSeeInternalMessages(c *gin.Context, rolesRelationRepo *repository.RolesRelationsRepository) (bool, *errx.APIError) {
+	claims, err := authClaims(c)
+	if err != nil {
+		return false, nil
+	}
+	return rolesRelationRepo.IsStaff(c.Request.Context(), claims.UserID)
+}
+
+// stripInternalMessages removes internal notes from every ticket of a list
+func stripInternalMessages(tickets []dto.TicketResponse) {
+	for i := range tickets {
+		tickets[i].StripInternalMessages()
+	}
+}
+
+// publishTicketEvent pushes an event to the live streams of its ticket. A failure only
+// costs clients a live update they get on their next fetch, so it is logged, not returned.
+func publishTicketEvent(c *gin.Context, streamService *stream.StreamService, event dto.TicketStreamEvent) {
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	// the response may be written before publishing finishes
+	ctx := context.WithoutCancel(c.Request.Context())
+	if err := streamService.Publish(ctx, &event); err != nil {
+		log.Printf("⚠️ failed to publish %s event of ticket %s: %v", event.Type, event.TicketID, err)
+	}
+}
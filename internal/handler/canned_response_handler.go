@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"net/http"
+	"ticket-api/internal/dto"
+	"ticket-api/internal/errx"
+	"ticket-api/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CannedResponseHandler handles canned response library HTTP requests
+type CannedResponseHandler struct {
+	CannedResponseRepo *repository.CannedResponsesRepository
+	RolesRelationRepo  *repository.RolesRelationsRepository
+}
+
+// NewCannedResponseHandler creates a new CannedResponseHandler instance
+func NewCannedResponseHandler(
+	cannedResponseRepo *repository.CannedResponsesRepository,
+	rolesRelationRepo *repository.RolesRelationsRepository,
+) *CannedResponseHandler {
+	return &CannedResponseHandler{
+		CannedResponseRepo: cannedResponseRepo,
+		RolesRelationRepo:  rolesRelationRepo,
+	}
+}
+
+// CreateCannedResponseHandler handles POST /cannedResponses/CreateCannedResponse/
+// @Summary Add a canned response
+// @Description Adds a reply template usable on tickets of one department and ticket type, or of every one if none is given. Only staff may do this.
+// @Description The body may contain the placeholders {{username}}, {{trackCode}} and {{departmentName}}
+// @Tags CannedResponse
+// @Accept json
+// @Produce json
+// @Param request body dto.CannedResponseRequest true "Canned response data"
+// @Success 201 {object} dto.IDResponse[int64]
+// @Failure 400 {object} errx.APIError
+// @Failure 403 {object} errx.APIError
+// @Failure 500 {object} errx.APIError
+// @Router /cannedResponses/CreateCannedResponse/ [post]
+func (h *CannedResponseHandler) CreateCannedResponseHandler(c *gin.Context) {
+	var req dto.CannedResponseRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	claims, ok := requireStaffClaims(c, h.RolesRelationRepo)
+	if !ok {
+		return
+	}
+
+	id, err := h.CannedResponseRepo.AddCannedResponse(c.Request.Context(), claims.UserID, req)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.IDResponse[int64]{ID: id})
+}
+
+// UpdateCannedResponseHandler handles POST /cannedResponses/UpdateCannedResponse/
+// @Summary Update a canned response
+// @Description Replaces the title, body, scope and active flag of a canned response. Only staff may do this
+// @Tags CannedResponse
+// @Accept json
+// @Produce json
+// @Param request body dto.CannedResponseUpdateRequest true "Canned response data"
+// @Success 204
+// @Failure 400 {object} errx.APIError
+// @Failure 403 {object} errx.APIError
+// @Failure 404 {object} errx.APIError
+// @Failure 500 {object} errx.APIError
+// @Router /cannedResponses/UpdateCannedResponse/ [post]
+func (h *CannedResponseHandler) UpdateCannedResponseHandler(c *gin.Context) {
+	var req dto.CannedResponseUpdateRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if _, ok := requireStaffClaims(c, h.RolesRelationRepo); !ok {
+		return
+	}
+
+	if err := h.CannedResponseRepo.UpdateCannedResponse(c.Request.Context(), req); err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// DeleteCannedResponseHandler handles POST /cannedResponses/DeleteCannedResponse/
+// @Summary Delete a canned response
+// @Description Deletes a canned response, its usage statistics are kept. Only staff may do this
+// @Tags CannedResponse
+// @Accept json
+// @Produce json
+// @Param request body dto.IDRequest[int64] true "Canned response ID"
+// @Success 204
+// @Failure 400 {object} errx.APIError
+// @Failure 403 {object} errx.APIError
+// @Failure 404 {object} errx.APIError
+// @Failure 500 {object} errx.APIError
+// @Router /cannedResponses/DeleteCannedResponse/ [post]
+func (h *CannedResponseHandler) DeleteCannedResponseHandler(c *gin.Context) {
+	var req dto.IDRequest[int64]
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if _, ok := requireStaffClaims(c, h.RolesRelationRepo); !ok {
+		return
+	}
+
+	if err := h.CannedResponseRepo.DeleteCannedResponse(c.Request.Context(), req.ID); err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetCannedResponsesHandler handles GET /cannedResponses/GetCannedResponses/
+// @Summary List canned responses
+// @Description Returns the active canned responses, limited to global ones and those of a department and ticket type if given. Only staff may do this
+// @Tags CannedResponse
+// @Produce json
+// @Param departmentId query int false "Department ID"
+// @Param ticketTypeId query int false "Ticket type ID"
+// @Param includeInactive query bool false "Also list inactive responses"
+// @Success 200 {array} dto.CannedResponseDTO
+// @Failure 400 {object} errx.APIError
+// @Failure 403 {object} errx.APIError
+// @Failure 500 {object} errx.APIError
+// @Router /cannedResponses/GetCannedResponses/ [get]
+func (h *CannedResponseHandler) GetCannedResponsesHandler(c *gin.Context) {
+	var query dto.CannedResponseQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		appErr := errx.Respond(errx.ErrBadRequest, err)
+		c.JSON(appErr.HTTPStatus, appErr)
+		return
+	}
+
+	if _, ok := requireStaffClaims(c, h.RolesRelationRepo); !ok {
+		return
+	}
+
+	responses, err := h.CannedResponseRepo.GetCannedResponses(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, responses)
+}
+
+// GetCannedResponseUsageHandler handles POST /cannedResponses/GetCannedResponseUsage/
+// @Summary Count posts per canned response
+// @Description Returns how often each canned response was posted in a period and by how many agents, most used first. Only staff may do this
+// @Tags CannedResponse
+// @Accept json
+// @Produce json
+// @Param request body dto.CannedResponseUsageRequest true "Period and department"
+// @Success 200 {array} dto.CannedResponseUsageDTO
+// @Failure 400 {object} errx.APIError
+// @Failure 403 {object} errx.APIError
+// @Failure 500 {object} errx.APIError
+// @Router /cannedResponses/GetCannedResponseUsage/ [post]
+func (h *CannedResponseHandler) GetCannedResponseUsageHandler(c *gin.Context) {
+	var req dto.CannedResponseUsageRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if _, ok := requireStaffClaims(c, h.RolesRelationRepo); !ok {
+		return
+	}
+
+	usage, err := h.CannedResponseRepo.GetCannedResponseUsage(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, usage)
+}
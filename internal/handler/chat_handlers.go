@@ -1,30 +1,60 @@
 package handler
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"strings"
 	"ticket-api/internal/config"
 	"ticket-api/internal/dto"
 	"ticket-api/internal/errx"
 	"ticket-api/internal/repository"
+	"ticket-api/internal/services/stream"
+	"ticket-api/internal/services/token"
 
 	"github.com/gin-gonic/gin"
 )
 
 type ChatHandler struct {
-	ticketRepo *repository.TicketRepository
-	chatRepo   *repository.ChatRepository
+	ticketRepo         *repository.TicketRepository
+	chatRepo           *repository.ChatRepository
+	rolesRelationRepo  *repository.RolesRelationsRepository
+	userRepo           *repository.UsersRepository
+	departmentRepo     *repository.DepartmentsRepository
+	cannedResponseRepo *repository.CannedResponsesRepository
+	stream             *stream.StreamService
+	tokenService       *token.TokenService
 }
 
 // NewChatHandler constructor
-func NewChatHandler(ticketRepo *repository.TicketRepository, chatRepo *repository.ChatRepository) *ChatHandler {
-	return &ChatHandler{ticketRepo: ticketRepo, chatRepo: chatRepo}
+func NewChatHandler(
+	ticketRepo *repository.TicketRepository,
+	chatRepo *repository.ChatRepository,
+	rolesRelationRepo *repository.RolesRelationsRepository,
+	userRepo *repository.UsersRepository,
+	departmentRepo *repository.DepartmentsRepository,
+	cannedResponseRepo *repository.CannedResponsesRepository,
+	streamService *stream.StreamService,
+	tokenService *token.TokenService,
+) *ChatHandler {
+	return &ChatHandler{
+		ticketRepo:         ticketRepo,
+		chatRepo:           chatRepo,
+		rolesRelationRepo:  rolesRelationRepo,
+		userRepo:           userRepo,
+		departmentRepo:     departmentRepo,
+		cannedResponseRepo: cannedResponseRepo,
+		stream:             streamService,
+		tokenService:       tokenService,
+	}
 }
 
 // CreateChatHandler handles POST /tickets/:id/CreateChat/
 // @Summary Add chat message to a ticket
 // @Description Adds a new chat message to an existing ticket
+// @Description Staff may send a templateId instead of a message to post a canned response with its placeholders rendered for the ticket
 // @Tags Ticket
 // @Accept json
 // @Produce json
@@ -32,6 +62,7 @@ func NewChatHandler(ticketRepo *repository.TicketRepository, chatRepo *repositor
 // @Param chat body dto.ChatMessageCreateRequest true "Chat message data"
 // @Success 201 {object} dto.ChatMessageDTO
 // @Failure 400 {object} errx.Error
+// @Failure 403 {object} errx.Error "Attachment uploaded by someone else or expired"
 // @Failure 404 {object} errx.Error
 // @Failure 500 {object} errx.Error
 // @Router /tickets/:id/CreateChat/ [post]
@@ -46,30 +77,345 @@ func (h *ChatHandler) CreateChatHandler(c *gin.Context) {
 		return
 	}
 
+	h.createChatMessage(c, ticketID, &chatDTO)
+}
+
+// CreateInternalNoteHandler handles POST /tickets/:id/CreateInternalNote/
+// @Summary Add an internal note to a ticket
+// @Description Adds a chat message only staff can read. The requester never sees it. Only staff may do this
+// @Tags Ticket
+// @Accept json
+// @Produce json
+// @Param id path string true "Ticket ID"
+// @Param chat body dto.ChatMessageCreateRequest true "Note data, senderId is ignored"
+// @Success 201 {object} dto.ChatMessageDTO
+// @Failure 400 {object} errx.APIError
+// @Failure 403 {object} errx.APIError
+// @Failure 404 {object} errx.APIError
+// @Failure 500 {object} errx.APIError
+// @Router /tickets/:id/CreateInternalNote/ [post]
+func (h *ChatHandler) CreateInternalNoteHandler(c *gin.Context) {
+	ticketID := c.Param("id")
+
+	var chatDTO dto.ChatMessageCreateRequest
+	if !bindJSON(c, &chatDTO) {
+		return
+	}
+
+	claims, err := authClaims(c)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+	if !requireStaff(c, h.rolesRelationRepo, claims.UserID) {
+		return
+	}
+
+	chatDTO.SenderID = claims.UserID
+	chatDTO.Internal = true
+
+	h.createChatMessage(c, ticketID, &chatDTO)
+}
+
+// createChatMessage adds the message to the chat of the ticket and responds with it
+func (h *ChatHandler) createChatMessage(c *gin.Context, ticketID string, chatDTO *dto.ChatMessageCreateRequest) {
+	updatedChat, err := h.addChatMessage(c, ticketID, chatDTO)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	// Respond with updated chat
+	c.JSON(http.StatusCreated, updatedChat)
+}
+
+// addChatMessage renders the canned response of the message if any, checks the attachment
+// limit of the ticket, adds the message to its chat and pushes it to the live streams of the ticket
+func (h *ChatHandler) addChatMessage(c *gin.Context, ticketID string, chatDTO *dto.ChatMessageCreateRequest) (*dto.ChatMessageDTO, *errx.APIError) {
+	if chatDTO.TemplateID != nil {
+		if err := h.renderCannedResponse(c, ticketID, chatDTO); err != nil {
+			return nil, err
+		}
+	}
+
 	// Check attachment limit
 	if len(chatDTO.Attachments) > 0 {
 		count, repoErr := h.ticketRepo.GetTicketAttachmentCount(c.Request.Context(), ticketID)
 		if repoErr != nil {
-			c.JSON(repoErr.HTTPStatus, repoErr)
-			return
+			return nil, repoErr
 		}
 
 		total := count + len(chatDTO.Attachments)
 		if total > config.Get().TicketConfig.MaxTicketUploadFile {
 			apiErr := errx.Respond(errx.ErrMaxTicketFilesExceeded, errors.New(""))
 			apiErr.Err.Message += fmt.Sprintf(" حداکثر فایل مجاز برای هر تیکت: %d", config.Get().TicketConfig.MaxTicketUploadFile)
-			c.JSON(apiErr.HTTPStatus, apiErr)
-			return
+			return nil, apiErr
 		}
 	}
 
+	// Only replies of signed-in staff count as the first response of the ticket
+	senderIsStaff := false
+	if claims := requestClaims(c, h.tokenService); claims != nil && claims.UserID == chatDTO.SenderID {
+		isStaff, err := h.rolesRelationRepo.IsStaff(c.Request.Context(), claims.UserID)
+		if err != nil {
+			return nil, err
+		}
+		senderIsStaff = isStaff
+	}
+
 	// Create chat message for ticket
-	updatedChat, repoErr := h.chatRepo.CreateChatMessageForTicket(c.Request.Context(), ticketID, &chatDTO)
+	updatedChat, repoErr := h.chatRepo.CreateChatMessageForTicket(c.Request.Context(), ticketID, uploaderOf(c), senderIsStaff, chatDTO)
 	if repoErr != nil {
-		c.JSON(repoErr.HTTPStatus, repoErr)
+		return nil, repoErr
+	}
+
+	publishTicketEvent(c, h.stream, dto.TicketStreamEvent{
+		Type:     dto.TicketStreamEventChat,
+		TicketID: ticketID,
+		ActorID:  updatedChat.SenderID,
+		Chat:     updatedChat,
+	})
+
+	// The message is already posted, a lost count only skews the statistics
+	if chatDTO.TemplateID != nil {
+		if err := h.cannedResponseRepo.RecordCannedResponseUse(context.WithoutCancel(c.Request.Context()), *chatDTO.TemplateID, updatedChat.SenderID, ticketID); err != nil {
+			log.Printf("⚠️ failed to record use of canned response %d on ticket %s: %v", *chatDTO.TemplateID, ticketID, err)
+		}
+	}
+
+	return updatedChat, nil
+}
+
+// renderCannedResponse replaces the message with the canned response of the request, its
+// placeholders filled in for the ticket. Only staff may post canned responses, and only those
+// scoped to the department and type of the ticket.
+func (h *ChatHandler) renderCannedResponse(c *gin.Context, ticketID string, chatDTO *dto.ChatMessageCreateRequest) *errx.APIError {
+	ctx := c.Request.Context()
+
+	if strings.TrimSpace(chatDTO.Message) != "" {
+		return errx.Respond(errx.ErrBadRequest, errors.New("message and templateId cannot be combined"))
+	}
+
+	claims, err := authClaims(c)
+	if err != nil {
+		return err
+	}
+	isStaff, err := h.rolesRelationRepo.IsStaff(ctx, claims.UserID)
+	if err != nil {
+		return err
+	}
+	if !isStaff {
+		return errx.Respond(errx.ErrStaffOnly, errors.New("only staff can post canned responses"))
+	}
+
+	ticket, err := h.ticketRepo.GetTicketByID(ctx, ticketID, dto.TicketChatOptions{SkipChat: true})
+	if err != nil {
+		return err
+	}
+
+	response, err := h.cannedResponseRepo.GetCannedResponseForTicket(ctx, *chatDTO.TemplateID, ticket.DepartmentID, ticket.TicketTypeID)
+	if err != nil {
+		return err
+	}
+
+	values := dto.CannedResponseValues{TrackCode: ticket.TrackCode}
+	owner, err := h.userRepo.GetUserByID(ctx, ticket.UserID)
+	if err != nil {
+		return err
+	}
+	values.Username = owner.Username
+
+	departments, err := h.departmentRepo.GetAllDepartments(ctx)
+	if err != nil {
+		return err
+	}
+	for _, department := range departments {
+		if department.ID == ticket.DepartmentID {
+			values.DepartmentName = department.Title
+			break
+		}
+	}
+
+	// The canned response is posted by the agent who chose it
+	chatDTO.SenderID = claims.UserID
+	chatDTO.Message = dto.RenderCannedResponse(response.Body, values)
+	return nil
+}
+
+// GetTicketMessagesHandler handles GET /tickets/:id/Messages/
+// @Summary Page through the chat of a ticket
+// @Description Returns the latest messages of a ticket, or the messages before or after a message, oldest first.
+// @Description Staff may read any ticket and see internal notes, other users only their own tickets
+// @Tags Ticket
+// @Produce json
+// @Param id path string true "Ticket ID"
+// @Param before query string false "Only messages older than this message ID"
+// @Param after query string false "Only messages newer than this message ID"
+// @Param limit query int false "Page size"
+// @Success 200 {object} dto.ChatMessagesPage
+// @Failure 400 {object} errx.APIError
+// @Failure 404 {object} errx.APIError
+// @Failure 500 {object} errx.APIError
+// @Router /tickets/{id}/Messages/ [get]
+func (h *ChatHandler) GetTicketMessagesHandler(c *gin.Context) {
+	var query dto.ChatMessagesQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		appErr := errx.Respond(errx.ErrBadRequest, err)
+		c.JSON(appErr.HTTPStatus, appErr)
 		return
 	}
 
-	// Respond with updated chat
-	c.JSON(http.StatusCreated, updatedChat)
+	claims, err := authClaims(c)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	isStaff, err := h.rolesRelationRepo.IsStaff(c.Request.Context(), claims.UserID)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	page, err := h.chatRepo.GetTicketMessages(c.Request.Context(), c.Param("id"), claims.UserID, isStaff, query)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// EditChatHandler handles POST /tickets/:id/EditChat/
+// @Summary Edit a chat message
+// @Description Replaces the text of the user's own message within the edit window. The previous text is kept as a revision
+// @Tags Ticket
+// @Accept json
+// @Produce json
+// @Param id path string true "Ticket ID"
+// @Param request body dto.ChatMessageEditRequest true "Message ID and new text"
+// @Success 200 {object} dto.ChatMessageDTO
+// @Failure 400 {object} errx.APIError
+// @Failure 403 {object} errx.APIError
+// @Failure 404 {object} errx.APIError
+// @Failure 409 {object} errx.APIError
+// @Failure 500 {object} errx.APIError
+// @Router /tickets/:id/EditChat/ [post]
+func (h *ChatHandler) EditChatHandler(c *gin.Context) {
+	var req dto.ChatMessageEditRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	claims, err := authClaims(c)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	updated, err := h.chatRepo.EditChatMessage(c.Request.Context(), c.Param("id"), req.MessageID, claims.UserID, req.Message)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	publishTicketEvent(c, h.stream, dto.TicketStreamEvent{
+		Type:     dto.TicketStreamEventChatEdited,
+		TicketID: c.Param("id"),
+		ActorID:  claims.UserID,
+		Chat:     updated,
+	})
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeleteChatHandler handles POST /tickets/:id/DeleteChat/
+// @Summary Delete a chat message
+// @Description Deletes the user's own message within the edit window and removes its attachments
+// @Tags Ticket
+// @Accept json
+// @Produce json
+// @Param id path string true "Ticket ID"
+// @Param request body dto.ChatMessageDeleteRequest true "Message ID"
+// @Success 204
+// @Failure 400 {object} errx.APIError
+// @Failure 403 {object} errx.APIError
+// @Failure 404 {object} errx.APIError
+// @Failure 409 {object} errx.APIError
+// @Failure 500 {object} errx.APIError
+// @Router /tickets/:id/DeleteChat/ [post]
+func (h *ChatHandler) DeleteChatHandler(c *gin.Context) {
+	var req dto.ChatMessageDeleteRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	claims, err := authClaims(c)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	deleted, err := h.chatRepo.DeleteChatMessage(c.Request.Context(), c.Param("id"), req.MessageID, claims.UserID)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	publishTicketEvent(c, h.stream, dto.TicketStreamEvent{
+		Type:     dto.TicketStreamEventChatDeleted,
+		TicketID: c.Param("id"),
+		ActorID:  claims.UserID,
+		Chat:     deleted,
+	})
+
+	c.Status(http.StatusNoContent)
+}
+
+// MarkTicketReadHandler handles POST /tickets/MarkTicketRead/
+// @Summary Mark the chat of a ticket as read
+// @Description Moves the read receipt of the user forward to the given message, or to the latest message the user can see. Receipts never move back
+// @Tags Ticket
+// @Accept json
+// @Produce json
+// @Param request body dto.MarkTicketReadRequest true "Ticket ID and optional message ID"
+// @Success 200 {object} dto.ReadReceiptDTO
+// @Failure 400 {object} errx.APIError
+// @Failure 404 {object} errx.APIError
+// @Failure 409 {object} errx.APIError
+// @Failure 500 {object} errx.APIError
+// @Router /tickets/MarkTicketRead/ [post]
+func (h *ChatHandler) MarkTicketReadHandler(c *gin.Context) {
+	var req dto.MarkTicketReadRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	claims, err := authClaims(c)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	isStaff, err := h.rolesRelationRepo.IsStaff(c.Request.Context(), claims.UserID)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	receipt, msg, err := h.chatRepo.MarkTicketRead(c.Request.Context(), req.TicketID, claims.UserID, isStaff, req.MessageID)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	publishTicketEvent(c, h.stream, dto.TicketStreamEvent{
+		Type:     dto.TicketStreamEventRead,
+		TicketID: req.TicketID,
+		ActorID:  claims.UserID,
+		Receipt:  receipt,
+		Internal: msg.Internal,
+	})
+
+	c.JSON(http.StatusOK, receipt)
 }
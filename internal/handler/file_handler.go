@@ -3,6 +3,8 @@ package handler
 import (
 	"errors"
 	"fmt"
+	"io"
+	"log"
 	"mime/multipart"
 	"net/http"
 	"path/filepath"
@@ -11,33 +13,54 @@ import (
 	"ticket-api/internal/config"
 	"ticket-api/internal/dto"
 	"ticket-api/internal/errx"
+	"ticket-api/internal/repository"
 	"ticket-api/internal/services/storage"
+	"ticket-api/internal/services/token"
 	"ticket-api/internal/util"
 
+	"github.com/gabriel-vasile/mimetype"
 	"github.com/gin-gonic/gin"
 )
 
 type FileHandler struct {
-	storage *storage.StorageService
+	storage  *storage.StorageService
+	chatRepo *repository.ChatRepository
+	readers  *ticketReaderAuth
 }
 
-func NewFileHandler(storage *storage.StorageService) *FileHandler {
+func NewFileHandler(
+	storage *storage.StorageService,
+	ticketRepo *repository.TicketRepository,
+	chatRepo *repository.ChatRepository,
+	userRepo *repository.UsersRepository,
+	rolesRelationRepo *repository.RolesRelationsRepository,
+	tokenService *token.TokenService,
+) *FileHandler {
 	return &FileHandler{
-		storage: storage,
+		storage:  storage,
+		chatRepo: chatRepo,
+		readers: &ticketReaderAuth{
+			ticketRepo:        ticketRepo,
+			userRepo:          userRepo,
+			rolesRelationRepo: rolesRelationRepo,
+			tokenService:      tokenService,
+		},
 	}
 }
 
 // UploadTicketFile godoc
 // @Summary      Upload a ticket file
 // @Description  Upload a file to the temporary storage for a ticket. File must be multipart/form-data with field name `file`.
+// @Description  Needs an auth or captcha cookie. Only the same user, or the same captcha token, may attach the file to a ticket or chat message, until storage.temp_upload_ttl_minutes passes.
 // @Tags         TicketFile
 // @Accept       multipart/form-data
 // @Produce      json
 // @Param        file  formData  file  true  "Ticket file to upload"
 // @Success      200   {object}  dto.IDResponse[string]  "Returns uploaded file ID"
 // @Failure      400   {object}  errx.APIError
+// @Failure      401   {object}  errx.APIError
 // @Failure      413   {object}  errx.APIError  "File too large"
-// @Failure      415   {object}  errx.APIError  "Unsupported file extension"
+// @Failure      415   {object}  errx.APIError  "File content does not match its extension"
 // @Failure      500   {object}  errx.APIError
 // @Router       /files/UploadTicketFile/ [post]
 func (h *FileHandler) UploadTicketFileHandler(c *gin.Context) {
@@ -65,7 +88,13 @@ func (h *FileHandler) UploadTicketFileHandler(c *gin.Context) {
 
 	defer file.Close()
 
-	ext, apiErr := parseTicketFileExtension(header)
+	ext, allowedTypes, apiErr := parseTicketFileExtension(header)
+	if apiErr != nil {
+		c.JSON(apiErr.HTTPStatus, apiErr)
+		return
+	}
+
+	contentType, apiErr := detectTicketFileType(file, ext, allowedTypes)
 	if apiErr != nil {
 		c.JSON(apiErr.HTTPStatus, apiErr)
 		return
@@ -73,7 +102,7 @@ func (h *FileHandler) UploadTicketFileHandler(c *gin.Context) {
 
 	filename := fmt.Sprintf("%s%s", util.GenerateUUID(), ext)
 
-	_, apiErr = h.storage.UploadTicketFileToTemp(c, filename, file, header.Size, c.ContentType())
+	_, apiErr = h.storage.UploadTicketFileToTemp(c.Request.Context(), uploaderOf(c), filename, file, header.Size, contentType)
 	if apiErr != nil {
 		c.JSON(apiErr.HTTPStatus, apiErr)
 		return
@@ -84,19 +113,22 @@ func (h *FileHandler) UploadTicketFileHandler(c *gin.Context) {
 
 // DownloadTicketFileHandler godoc
 // @Summary      Download a ticket file
-// @Description  Generates a temporary presigned URL for the given file and redirects the client to that URL to start the download.
+// @Description  Generates a temporary presigned URL for a file attached to a chat message of the ticket.
+// @Description  Logged-in users are authorized by their auth cookie; staff may get files of any ticket, other users only of their own. Anonymous requesters pass the trackCode and username of the ticket.
+// @Description  Files of internal notes are only linked for staff. Every issued link is logged.
 // @Tags         TicketFile
 // @Produce      json
 // @Param        objectName  path  string  true  "File object name (UUID + extension)"
-// @Param 			 ticketId body dto.IDRequest[string] true "ticket ID for file location"
-// @Success      200         "Give MinIO download URL"
+// @Param        request     body  dto.TicketFileLinkRequest  true  "Ticket ID, and track code and username for anonymous requesters"
+// @Success      200         {object}  dto.TicketDownloadLink
 // @Failure      400         {object}  errx.APIError
-// @Failure      404         {object}  errx.APIError  "File not found"
+// @Failure      401         {object}  errx.APIError
+// @Failure      404         {object}  errx.APIError  "Ticket or file not found"
 // @Failure      500         {object}  errx.APIError
 // @Router       /files/GetDownloadLinkTicketFile/{objectName}/ [post]
 func (h *FileHandler) GetDownloadLinkTicketFileHandler(c *gin.Context) {
 	objectName, err := util.ParseObjectName(c.Param("objectName"))
-	var req dto.IDRequest[string]
+	var req dto.TicketFileLinkRequest
 	if !bindJSON(c, &req) {
 		return
 	}
@@ -107,28 +139,104 @@ func (h *FileHandler) GetDownloadLinkTicketFileHandler(c *gin.Context) {
 		return
 	}
 
+	reader, appErr := h.readers.authorize(c, req.ID, req.TrackCode, req.Username)
+	if appErr != nil {
+		c.JSON(appErr.HTTPStatus, appErr)
+		return
+	}
+
+	// only files of the chat are linked, and those of internal notes only for staff
+	msg, appErr := h.chatRepo.GetAttachmentMessage(c.Request.Context(), req.ID, objectName)
+	if appErr != nil {
+		c.JSON(appErr.HTTPStatus, appErr)
+		return
+	}
+	if msg.Internal && !reader.IsStaff {
+		appErr := errx.Respond(errx.ErrFileNotFound, fmt.Errorf("%s is attached to an internal note", objectName))
+		c.JSON(appErr.HTTPStatus, appErr)
+		return
+	}
+
 	url, appErr := h.storage.GetPresignedTicketFileURL(c.Request.Context(), req.ID, objectName)
 	if appErr != nil {
 		c.JSON(appErr.HTTPStatus, appErr)
 		return
 	}
 
+	log.Printf("🔗 Download link of %s on ticket %s issued to %s from %s", objectName, req.ID, reader, c.ClientIP())
 	c.JSON(http.StatusOK, &dto.TicketDownloadLink{Url: url})
 }
 
+// DownloadTicketFileHandler godoc
+// @Summary      Download a ticket file by a signed link
+// @Description  Serves a file kept by the local storage driver. The links are issued by GetDownloadLinkTicketFile and expire.
+// @Tags         TicketFile
+// @Produce      octet-stream
+// @Param        objectName  path   string  true  "Object name of the file"
+// @Param        expires     query  int     true  "Expiry of the link, unix seconds"
+// @Param        signature   query  string  true  "Signature of the link"
+// @Success      200
+// @Failure      400  {object}  errx.APIError
+// @Failure      401  {object}  errx.APIError  "Invalid signature"
+// @Failure      404  {object}  errx.APIError  "File not found"
+// @Failure      410  {object}  errx.APIError  "Link expired"
+// @Router       /files/Download/{objectName} [get]
+func (h *FileHandler) DownloadTicketFileHandler(c *gin.Context) {
+	objectName, err := util.ParseObjectName(strings.TrimPrefix(c.Param("objectName"), "/"))
+	if err != nil {
+		apiErr := errx.Respond(errx.ErrBadRequest, err)
+		c.JSON(apiErr.HTTPStatus, apiErr)
+		return
+	}
+
+	file, info, apiErr := h.storage.GetSignedFile(c.Request.Context(), objectName, c.Query("expires"), c.Query("signature"))
+	if apiErr != nil {
+		c.JSON(apiErr.HTTPStatus, apiErr)
+		return
+	}
+	defer file.Close()
+
+	c.DataFromReader(http.StatusOK, info.Size, info.ContentType, file, map[string]string{
+		"Content-Disposition": fmt.Sprintf("attachment; filename=%q", filepath.Base(objectName)),
+		"Cache-Control":       "private, no-store",
+	})
+}
+
 // parseTicketFileExtension checks if the file's extension is supported.
-// If supported, it returns the *normalized* (lowercase) extension string.
+// If supported, it returns the *normalized* (lowercase) extension string and the MIME types
+// its content may have.
 // If not supported, it returns an empty string and a specific API error.
-func parseTicketFileExtension(file *multipart.FileHeader) (string, *errx.APIError) {
+func parseTicketFileExtension(file *multipart.FileHeader) (string, []string, *errx.APIError) {
 	allowedExts := config.Get().TicketConfig.AcceptableFilesForUpload
 	ext := strings.ToLower(filepath.Ext(file.Filename))
 
-	for _, allowed := range allowedExts {
+	for allowed, mimeTypes := range allowedExts {
 		if strings.EqualFold(allowed, ext) {
-			return ext, nil
+			return ext, mimeTypes, nil
 		}
 	}
 
 	errDetail := fmt.Sprintf("file extension %q is not supported", ext)
-	return "", errx.Respond(errx.ErrUnsupportedFileExtension, errors.New(errDetail))
+	return "", nil, errx.Respond(errx.ErrUnsupportedFileExtension, errors.New(errDetail))
+}
+
+// detectTicketFileType sniffs the content type of the file from its first bytes and checks it
+// is one of the types allowed for its extension. The file is rewound for the upload.
+func detectTicketFileType(file multipart.File, ext string, allowedTypes []string) (string, *errx.APIError) {
+	detected, err := mimetype.DetectReader(file)
+	if err != nil {
+		return "", errx.Respond(errx.ErrBadRequest, err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", errx.Respond(errx.ErrInternalServerError, err)
+	}
+
+	for _, allowed := range allowedTypes {
+		if detected.Is(allowed) {
+			return detected.String(), nil
+		}
+	}
+
+	errDetail := fmt.Sprintf("content of type %q is not allowed for extension %q", detected.String(), ext)
+	return "", errx.Respond(errx.ErrFileContentMismatch, errors.New(errDetail))
 }
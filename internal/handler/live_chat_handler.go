@@ -0,0 +1,248 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"ticket-api/internal/config"
+	"ticket-api/internal/dto"
+	"ticket-api/internal/errx"
+	"ticket-api/internal/util"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+// liveChatSession is one WebSocket connection to the live chat of a ticket
+type liveChatSession struct {
+	ticketID string
+	userID   int64
+	isStaff  bool
+	connID   string
+}
+
+// LiveChatHandler handles GET /tickets/:id/LiveChat/
+// @Summary Open the live chat of a ticket
+// @Description Upgrades to a WebSocket. Clients send JSON frames of type "message" (saved like CreateChat and answered with an "ack" carrying the clientId and the saved message) and "typing".
+// @Description The server pushes every event of the ticket stream, including "typing" and "presence" with the users viewing the ticket, and a "ping" frame on every heartbeat.
+// @Description Staff may join any ticket and send internal notes, other users only their own tickets.
+// @Tags Ticket
+// @Param id path string true "Ticket ID"
+// @Success 101
+// @Failure 401 {object} errx.APIError
+// @Failure 404 {object} errx.APIError
+// @Failure 500 {object} errx.APIError
+// @Router /tickets/{id}/LiveChat/ [get]
+func (h *ChatHandler) LiveChatHandler(c *gin.Context) {
+	claims, err := authClaims(c)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	ticket, err := h.ticketRepo.GetTicketByID(c.Request.Context(), c.Param("id"), dto.TicketChatOptions{SkipChat: true})
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	isStaff, err := h.rolesRelationRepo.IsStaff(c.Request.Context(), claims.UserID)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+	if !isStaff && ticket.UserID != claims.UserID {
+		appErr := errx.Respond(errx.ErrTicketNotFound, errors.New("user did not create this ticket"))
+		c.JSON(appErr.HTTPStatus, appErr)
+		return
+	}
+
+	session := &liveChatSession{
+		ticketID: strings.ToLower(ticket.ID),
+		userID:   claims.UserID,
+		isStaff:  isStaff,
+		connID:   util.GenerateUUID(),
+	}
+
+	// The CORS middleware already rejected foreign origins, so no handshake check is needed here
+	server := websocket.Server{Handler: func(ws *websocket.Conn) {
+		h.serveLiveChat(c, ws, session)
+	}}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// serveLiveChat relays the ticket stream to the connection and handles the frames of the client
+// until either side closes it
+func (h *ChatHandler) serveLiveChat(c *gin.Context, ws *websocket.Conn, session *liveChatSession) {
+	defer ws.Close()
+
+	// Hijacked connections keep the read and write timeouts of the HTTP server
+	if err := ws.SetDeadline(time.Time{}); err != nil {
+		return
+	}
+	ws.MaxPayloadBytes = int(config.Get().App.MaxJsonRequestSize << 10)
+
+	// The request context is not canceled when a hijacked client goes away
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+
+	events, err := h.stream.Subscribe(ctx, session.ticketID)
+	if err != nil {
+		_ = websocket.JSON.Send(ws, dto.LiveChatAckFrame{
+			Type:  dto.LiveChatFrameError,
+			Error: errx.Respond(errx.ErrServiceUnavailable, err),
+		})
+		return
+	}
+
+	frames := make(chan []byte)
+	go func() {
+		defer cancel()
+		for {
+			var frame []byte
+			if err := websocket.Message.Receive(ws, &frame); err != nil {
+				return
+			}
+			select {
+			case frames <- frame:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	heartbeat := time.Duration(config.Get().TicketConfig.StreamHeartbeatSeconds) * time.Second
+	h.updatePresence(c, session, heartbeat, true)
+	defer h.leaveLiveChat(c, session)
+
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if event.IsInternal() && !session.isStaff {
+				continue
+			}
+			if err := websocket.JSON.Send(ws, event); err != nil {
+				return
+			}
+		case frame := <-frames:
+			reply := h.handleLiveChatFrame(c, session, frame)
+			if reply == nil {
+				continue
+			}
+			if err := websocket.JSON.Send(ws, reply); err != nil {
+				return
+			}
+		case <-ticker.C:
+			h.updatePresence(c, session, heartbeat, false)
+			if err := websocket.JSON.Send(ws, dto.LiveChatAckFrame{Type: dto.LiveChatFramePing}); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// handleLiveChatFrame saves or broadcasts a client frame. Message frames are answered with
+// an ack, typing frames only when they are rejected.
+func (h *ChatHandler) handleLiveChatFrame(c *gin.Context, session *liveChatSession, data []byte) *dto.LiveChatAckFrame {
+	var frame dto.LiveChatClientFrame
+	if err := json.Unmarshal(data, &frame); err != nil {
+		return &dto.LiveChatAckFrame{Type: dto.LiveChatFrameError, Error: errx.Respond(errx.ErrBadRequest, err)}
+	}
+
+	if frame.Internal && !session.isStaff {
+		return &dto.LiveChatAckFrame{
+			Type:     dto.LiveChatFrameError,
+			ClientID: frame.ClientID,
+			Error:    errx.Respond(errx.ErrStaffOnly, errors.New("only staff can write internal notes")),
+		}
+	}
+
+	switch frame.Type {
+	case dto.LiveChatFrameMessage:
+		if strings.TrimSpace(frame.Message) == "" && len(frame.Attachments) == 0 && frame.TemplateID == nil {
+			return &dto.LiveChatAckFrame{
+				Type:     dto.LiveChatFrameError,
+				ClientID: frame.ClientID,
+				Error:    errx.Respond(errx.ErrBadRequest, errors.New("message, attachments or templateId are required")),
+			}
+		}
+
+		chat, err := h.addChatMessage(c, session.ticketID, &dto.ChatMessageCreateRequest{
+			SenderID:    session.userID,
+			Message:     frame.Message,
+			Attachments: frame.Attachments,
+			TemplateID:  frame.TemplateID,
+			Internal:    frame.Internal,
+		})
+		if err != nil {
+			return &dto.LiveChatAckFrame{Type: dto.LiveChatFrameError, ClientID: frame.ClientID, Error: err}
+		}
+		return &dto.LiveChatAckFrame{Type: dto.LiveChatFrameAck, ClientID: frame.ClientID, Chat: chat}
+
+	case dto.LiveChatFrameTyping:
+		typing := frame.Typing
+		publishTicketEvent(c, h.stream, dto.TicketStreamEvent{
+			Type:     dto.TicketStreamEventTyping,
+			TicketID: session.ticketID,
+			ActorID:  session.userID,
+			Typing:   &typing,
+			Internal: frame.Internal,
+		})
+		return nil
+
+	default:
+		return &dto.LiveChatAckFrame{
+			Type:     dto.LiveChatFrameError,
+			ClientID: frame.ClientID,
+			Error:    errx.Respond(errx.ErrBadRequest, errors.New("unknown frame type: "+frame.Type)),
+		}
+	}
+}
+
+// updatePresence refreshes the presence mark of the connection, which expires after two
+// missed heartbeats. When the user just joined, the new viewer list is published.
+func (h *ChatHandler) updatePresence(c *gin.Context, session *liveChatSession, heartbeat time.Duration, joined bool) {
+	ctx := context.WithoutCancel(c.Request.Context())
+	if err := h.stream.Join(ctx, session.ticketID, session.userID, session.connID, 2*heartbeat); err != nil {
+		log.Printf("⚠️ failed to update live chat presence of ticket %s: %v", session.ticketID, err)
+		return
+	}
+	if joined {
+		h.publishPresence(c, session)
+	}
+}
+
+// leaveLiveChat removes the connection from the viewers and publishes the new viewer list
+func (h *ChatHandler) leaveLiveChat(c *gin.Context, session *liveChatSession) {
+	ctx := context.WithoutCancel(c.Request.Context())
+	if err := h.stream.Leave(ctx, session.ticketID, session.userID, session.connID); err != nil {
+		log.Printf("⚠️ failed to leave live chat of ticket %s: %v", session.ticketID, err)
+		return
+	}
+	h.publishPresence(c, session)
+}
+
+func (h *ChatHandler) publishPresence(c *gin.Context, session *liveChatSession) {
+	viewers, err := h.stream.Viewers(context.WithoutCancel(c.Request.Context()), session.ticketID)
+	if err != nil {
+		log.Printf("⚠️ failed to read live chat viewers of ticket %s: %v", session.ticketID, err)
+		return
+	}
+	publishTicketEvent(c, h.stream, dto.TicketStreamEvent{
+		Type:     dto.TicketStreamEventPresence,
+		TicketID: session.ticketID,
+		ActorID:  session.userID,
+		Viewers:  viewers,
+	})
+}
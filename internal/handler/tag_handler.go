@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"ticket-api/internal/dto"
+	"ticket-api/internal/errx"
+	"ticket-api/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TagHandler handles tag catalog HTTP requests
+type TagHandler struct {
+	TagRepo           *repository.TagsRepository
+	TicketRepo        *repository.TicketRepository
+	RolesRelationRepo *repository.RolesRelationsRepository
+}
+
+// NewTagHandler creates a new TagHandler instance
+func NewTagHandler(
+	tagRepo *repository.TagsRepository,
+	ticketRepo *repository.TicketRepository,
+	rolesRelationRepo *repository.RolesRelationsRepository,
+) *TagHandler {
+	return &TagHandler{
+		TagRepo:           tagRepo,
+		TicketRepo:        ticketRepo,
+		RolesRelationRepo: rolesRelationRepo,
+	}
+}
+
+// CreateTagHandler handles POST /tags/CreateTag/
+// @Summary Add a tag to the catalog
+// @Description Adds a tag usable in one department, or in every department if none is given. Only staff may do this
+// @Tags Tag
+// @Accept json
+// @Produce json
+// @Param request body dto.TagCreateRequest true "Tag data"
+// @Success 201 {object} dto.IDResponse[int64]
+// @Failure 400 {object} errx.APIError
+// @Failure 403 {object} errx.APIError
+// @Failure 500 {object} errx.APIError
+// @Router /tags/CreateTag/ [post]
+func (h *TagHandler) CreateTagHandler(c *gin.Context) {
+	var req dto.TagCreateRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if _, ok := requireStaffClaims(c, h.RolesRelationRepo); !ok {
+		return
+	}
+
+	id, err := h.TagRepo.AddTag(c.Request.Context(), req.Title, req.Color, req.DepartmentID)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.IDResponse[int64]{ID: id})
+}
+
+// UpdateTagHandler handles POST /tags/UpdateTag/
+// @Summary Update a tag
+// @Description Replaces the title, color, department and active flag of a tag. Only staff may do this
+// @Tags Tag
+// @Accept json
+// @Produce json
+// @Param request body dto.TagUpdateRequest true "Tag data"
+// @Success 204
+// @Failure 400 {object} errx.APIError
+// @Failure 403 {object} errx.APIError
+// @Failure 404 {object} errx.APIError
+// @Failure 500 {object} errx.APIError
+// @Router /tags/UpdateTag/ [post]
+func (h *TagHandler) UpdateTagHandler(c *gin.Context) {
+	var req dto.TagUpdateRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if _, ok := requireStaffClaims(c, h.RolesRelationRepo); !ok {
+		return
+	}
+
+	if err := h.TagRepo.UpdateTag(c.Request.Context(), req.ID, req.Title, req.Color, req.DepartmentID, req.Active); err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetAllActiveTagsHandler handles GET /tags/GetAllActiveTags/
+// @Summary Get all active tags
+// @Description Returns the active tags, limited to global tags and those of a department if departmentId is given
+// @Tags Tag
+// @Accept json
+// @Produce json
+// @Param departmentId query int false "Department ID"
+// @Success 200 {array} dto.TagDTO
+// @Failure 400 {object} errx.APIError
+// @Failure 500 {object} errx.APIError
+// @Router /tags/GetAllActiveTags/ [get]
+func (h *TagHandler) GetAllActiveTagsHandler(c *gin.Context) {
+	var departmentID int64
+	if param := c.Query("departmentId"); param != "" {
+		var parseErr error
+		departmentID, parseErr = strconv.ParseInt(param, 10, 64)
+		if parseErr != nil {
+			appErr := errx.Respond(errx.ErrBadRequest, parseErr)
+			c.JSON(appErr.HTTPStatus, appErr)
+			return
+		}
+	}
+
+	activeTags, err := h.TagRepo.GetAllActiveTags(c.Request.Context(), departmentID)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	tagsDTO := make([]dto.TagDTO, len(activeTags))
+	for i := range activeTags {
+		tagsDTO[i] = *dto.ToTagDTO(&activeTags[i])
+	}
+
+	c.JSON(http.StatusOK, tagsDTO)
+}
+
+// GetTagUsageHandler handles POST /tags/GetTagUsage/
+// @Summary Count tickets per tag
+// @Description Returns how many tickets matching the filter carry each tag, most used first. Only staff may do this
+// @Tags Tag
+// @Accept json
+// @Produce json
+// @Param request body dto.TicketQueryParams true "Ticket filter"
+// @Success 200 {array} dto.TagUsageDTO
+// @Failure 400 {object} errx.APIError
+// @Failure 403 {object} errx.APIError
+// @Failure 500 {object} errx.APIError
+// @Router /tags/GetTagUsage/ [post]
+func (h *TagHandler) GetTagUsageHandler(c *gin.Context) {
+	var req dto.TicketQueryParams
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	claims, ok := requireStaffClaims(c, h.RolesRelationRepo)
+	if !ok {
+		return
+	}
+	if req.MyQueue {
+		req.AssigneeID = claims.UserID
+	}
+	req.ReaderID = claims.UserID
+	req.ReaderIsStaff = true
+
+	usage, err := h.TicketRepo.GetTagUsageCounts(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	// tags no longer in the catalog keep an empty title
+	catalog, err := h.TagRepo.GetTagsMap(c.Request.Context())
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+	for i := range usage {
+		if tag, ok := catalog[usage[i].TagID]; ok {
+			usage[i].Title = tag.Title
+			usage[i].Color = tag.Color
+		}
+	}
+
+	c.JSON(http.StatusOK, usage)
+}
@@ -1,14 +1,25 @@
 package handler
 
 import (
+	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
 	"net/http"
+	"slices"
+	"strconv"
 	"ticket-api/internal/config"
+	"ticket-api/internal/db/ticket_statuses"
+	"ticket-api/internal/db/ticket_type_fields"
 	"ticket-api/internal/dto"
 	"ticket-api/internal/errx"
+	"ticket-api/internal/model"
 	"ticket-api/internal/repository"
+	"ticket-api/internal/services/stream"
+	"ticket-api/internal/services/token"
 	"ticket-api/internal/util"
+	"time"
 
 	_ "ticket-api/internal/routes"
 
@@ -17,30 +28,56 @@ import (
 
 // TicketHandler handles ticket-related HTTP requests
 type TicketHandler struct {
-	TicketRepo         *repository.TicketRepository
-	TicketTypeRepo     *repository.TicketTypesRepository
-	TicketPriorityRepo *repository.TicketPrioritiesRepository
-	TicketStatusRepo   *repository.TicketStatusesRepository
-	UserRepo           *repository.UsersRepository
-	DepartmentRepo     *repository.DepartmentsRepository
+	TicketRepo          *repository.TicketRepository
+	TicketTypeRepo      *repository.TicketTypesRepository
+	TicketTypeFieldRepo *repository.TicketTypeFieldsRepository
+	TicketPriorityRepo  *repository.TicketPrioritiesRepository
+	TicketStatusRepo    *repository.TicketStatusesRepository
+	UserRepo            *repository.UsersRepository
+	DepartmentRepo      *repository.DepartmentsRepository
+	RolesRelationRepo   *repository.RolesRelationsRepository
+	TicketHistoryRepo   *repository.TicketHistoryRepository
+	SLAPolicyRepo       *repository.SLAPoliciesRepository
+	TagRepo             *repository.TagsRepository
+	Stream              *stream.StreamService
+	readers             *ticketReaderAuth
 }
 
 // NewTicketHandler creates a new TicketHandler instance
 func NewTicketHandler(
 	ticketRepo *repository.TicketRepository,
 	ticketTypeRepo *repository.TicketTypesRepository,
+	ticketTypeFieldRepo *repository.TicketTypeFieldsRepository,
 	ticketPriorityRepo *repository.TicketPrioritiesRepository,
 	ticketStatusRepo *repository.TicketStatusesRepository,
 	userRepo *repository.UsersRepository,
 	departmentRepo *repository.DepartmentsRepository,
+	rolesRelationRepo *repository.RolesRelationsRepository,
+	ticketHistoryRepo *repository.TicketHistoryRepository,
+	slaPolicyRepo *repository.SLAPoliciesRepository,
+	tagRepo *repository.TagsRepository,
+	streamService *stream.StreamService,
+	tokenService *token.TokenService,
 ) *TicketHandler {
 	return &TicketHandler{
-		TicketRepo:         ticketRepo,
-		TicketTypeRepo:     ticketTypeRepo,
-		TicketPriorityRepo: ticketPriorityRepo,
-		TicketStatusRepo:   ticketStatusRepo,
-		UserRepo:           userRepo,
-		DepartmentRepo:     departmentRepo,
+		TicketRepo:          ticketRepo,
+		TicketTypeRepo:      ticketTypeRepo,
+		TicketTypeFieldRepo: ticketTypeFieldRepo,
+		TicketPriorityRepo:  ticketPriorityRepo,
+		TicketStatusRepo:    ticketStatusRepo,
+		UserRepo:            userRepo,
+		DepartmentRepo:      departmentRepo,
+		RolesRelationRepo:   rolesRelationRepo,
+		TicketHistoryRepo:   ticketHistoryRepo,
+		SLAPolicyRepo:       slaPolicyRepo,
+		TagRepo:             tagRepo,
+		Stream:              streamService,
+		readers: &ticketReaderAuth{
+			ticketRepo:        ticketRepo,
+			userRepo:          userRepo,
+			rolesRelationRepo: rolesRelationRepo,
+			tokenService:      tokenService,
+		},
 	}
 }
 
@@ -53,6 +90,7 @@ func NewTicketHandler(
 // @Param ticket body dto.TicketCreateRequest true "Ticket data"
 // @Success 201 {object} dto.IDResponse[string]
 // @Failure 400 {object} errx.APIError
+// @Failure 403 {object} errx.APIError "Attachment uploaded by someone else or expired"
 // @Failure 409 {object} errx.APIError
 // @Failure 500 {object} errx.APIError
 // @Router /tickets/CreateTicket/ [post]
@@ -101,6 +139,13 @@ func (h *TicketHandler) CreateTicketHandler(c *gin.Context) {
 		return // Add return!
 	}
 
+	// check custom fields against the definitions of the ticket type
+	ticketDTO.CustomFields, err = h.TicketTypeFieldRepo.ValidateCustomFields(c.Request.Context(), ticketDTO.TicketTypeID, ticketDTO.CustomFields)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
 	// check department exists
 	isDepExists, err := h.DepartmentRepo.IsDepartmentExits(c.Request.Context(), int64(ticketDTO.DepartmentID))
 	if err != nil {
@@ -113,14 +158,36 @@ func (h *TicketHandler) CreateTicketHandler(c *gin.Context) {
 		return // Add return!
 	}
 
-	openStatus, err := h.TicketStatusRepo.GetOpenStatus(c.Request.Context())
+	initialStatus, err := h.TicketStatusRepo.GetInitialStatus(c.Request.Context())
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+	ticketDTO.TicketStatusID = initialStatus.ID
+
+	priority, err := h.TicketPriorityRepo.GetTicketPriority(c.Request.Context(), int(ticketDTO.UserID), int(ticketDTO.TicketTypeID))
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+	ticketDTO.Priority = int64(priority)
+
+	// start the SLA clock if a policy applies
+	policy, err := h.SLAPolicyRepo.GetMatchingPolicy(c.Request.Context(), ticketDTO.TicketTypeID, ticketDTO.DepartmentID, priority)
 	if err != nil {
 		c.JSON(err.HTTPStatus, err)
 		return
 	}
-	ticketDTO.TicketStatusID = openStatus.ID
+	if policy != nil {
+		ticketDTO.SLA = model.NewTicketSLA(
+			policy.ID,
+			time.Now(),
+			time.Duration(policy.FirstResponseMinutes)*time.Minute,
+			time.Duration(policy.ResolveMinutes)*time.Minute,
+		)
+	}
 
-	createdTicket, err := h.TicketRepo.CreateTicket(c.Request.Context(), &ticketDTO)
+	createdTicket, err := h.TicketRepo.CreateTicket(c.Request.Context(), uploaderOf(c), &ticketDTO)
 	if err != nil {
 		c.JSON(err.HTTPStatus, err)
 		return
@@ -132,6 +199,7 @@ func (h *TicketHandler) CreateTicketHandler(c *gin.Context) {
 // GetTicketByTrackCodeHandler handles POST /tickets/GetTicketByTrackCode/
 // @Summary Get ticket by track code
 // @Description Returns a ticket by its track code
+// @Description messagesLimit returns only the latest messages of the chat and beforeMessageId the messages older than that message; hasOlderMessages tells whether more can be loaded.
 // @Tags Ticket
 // @Accept json
 // @Produce json
@@ -169,7 +237,8 @@ func (h *TicketHandler) GetTicketByTrackCodeHandler(c *gin.Context) {
 	}
 
 	// Get ticket by track code
-	ticketDTO, err := h.TicketRepo.GetTicketByTrackCode(c.Request.Context(), req.TrackCode)
+	req.PublicOnly = true
+	ticketDTO, err := h.TicketRepo.GetTicketByTrackCode(c.Request.Context(), req.TrackCode, req.TicketChatOptions)
 	if err != nil {
 		c.JSON(err.HTTPStatus, err)
 		return
@@ -182,6 +251,9 @@ func (h *TicketHandler) GetTicketByTrackCodeHandler(c *gin.Context) {
 		return
 	}
 
+	// The requester never sees internal notes
+	ticketDTO.StripInternalMessages()
+
 	// Return the ticket
 	c.JSON(http.StatusOK, ticketDTO)
 }
@@ -189,6 +261,7 @@ func (h *TicketHandler) GetTicketByTrackCodeHandler(c *gin.Context) {
 // GetTicketByIDHandler handles POST /tickets/GetTicketByID/
 // @Summary Get ticket by ID
 // @Description Returns a ticket by its ID
+// @Description messagesLimit returns only the latest messages of the chat and beforeMessageId the messages older than that message; hasOlderMessages tells whether more can be loaded.
 // @Tags Ticket
 // @Accept json
 // @Produce json
@@ -206,11 +279,22 @@ func (h *TicketHandler) GetTicketByIDHandler(c *gin.Context) {
 		return
 	}
 
-	ticketDTO, err := h.TicketRepo.GetTicketByID(c.Request.Context(), req.ID)
+	canSeeInternal, err := canSeeInternalMessages(c, h.RolesRelationRepo)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	// Internal notes are left out before limiting, so the requester gets a full window
+	req.PublicOnly = !canSeeInternal
+	ticketDTO, err := h.TicketRepo.GetTicketByID(c.Request.Context(), req.ID, req.TicketChatOptions)
 	if err != nil {
 		c.JSON(err.HTTPStatus, err)
 		return
 	}
+	if !canSeeInternal {
+		ticketDTO.StripInternalMessages()
+	}
 
 	c.JSON(http.StatusOK, ticketDTO)
 }
@@ -236,6 +320,27 @@ func (h *TicketHandler) GetTicketsListHandler(c *gin.Context) {
 		return
 	}
 
+	claims, err := authClaims(c)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	// Resolve "my queue" to the current user
+	if req.MyQueue {
+		req.AssigneeID = claims.UserID
+	}
+
+	canSeeInternal, err := canSeeInternalMessages(c, h.RolesRelationRepo)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	// Unread counts are for the current user
+	req.ReaderID = claims.UserID
+	req.ReaderIsStaff = canSeeInternal
+
 	// Fetch tickets from repository
 	ticketsListDTO, err := h.TicketRepo.GetTickets(c.Request.Context(), req)
 	if err != nil {
@@ -243,6 +348,10 @@ func (h *TicketHandler) GetTicketsListHandler(c *gin.Context) {
 		return
 	}
 
+	if !canSeeInternal {
+		stripInternalMessages(ticketsListDTO.Items)
+	}
+
 	c.JSON(http.StatusOK, ticketsListDTO)
 }
 
@@ -272,6 +381,150 @@ func (h *TicketHandler) GetAllActiveTicketTypesHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, ticketTypesDTO)
 }
 
+// GetTicketTypeFieldsHandler handles GET /tickets/GetTicketTypeFields/
+// @Summary Get the custom fields of a ticket type
+// @Description Returns the active custom fields to fill in when creating a ticket of the given type
+// @Tags Ticket
+// @Accept json
+// @Produce json
+// @Param ticketTypeId query int true "Ticket type ID"
+// @Success 200 {array} dto.TicketTypeFieldDTO
+// @Failure 400 {object} errx.APIError
+// @Failure 500 {object} errx.APIError
+// @Router /tickets/GetTicketTypeFields/ [get]
+func (h *TicketHandler) GetTicketTypeFieldsHandler(c *gin.Context) {
+	ticketTypeID, parseErr := strconv.ParseInt(c.Query("ticketTypeId"), 10, 64)
+	if parseErr != nil {
+		appErr := errx.Respond(errx.ErrBadRequest, parseErr)
+		c.JSON(appErr.HTTPStatus, appErr)
+		return
+	}
+
+	fields, err := h.TicketTypeFieldRepo.GetTicketTypeFields(c.Request.Context(), ticketTypeID)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	fieldsDTO := make([]dto.TicketTypeFieldDTO, len(fields))
+	for i := range fields {
+		fieldDTO, convErr := dto.ToTicketTypeFieldDTO(&fields[i])
+		if convErr != nil {
+			appErr := errx.Respond(errx.ErrInternalServerError, convErr)
+			c.JSON(appErr.HTTPStatus, appErr)
+			return
+		}
+		fieldsDTO[i] = *fieldDTO
+	}
+
+	c.JSON(http.StatusOK, fieldsDTO)
+}
+
+// AddTicketTypeFieldHandler handles POST /tickets/AddTicketTypeField/
+// @Summary Add a custom field to a ticket type
+// @Description Defines a text, number, enum or date field that tickets of the type carry. Only staff may do this
+// @Tags Ticket
+// @Accept json
+// @Produce json
+// @Param request body dto.TicketTypeFieldRequest true "Field definition"
+// @Success 201 {object} dto.IDResponse[int64]
+// @Failure 400 {object} errx.APIError
+// @Failure 403 {object} errx.APIError
+// @Failure 404 {object} errx.APIError
+// @Failure 500 {object} errx.APIError
+// @Router /tickets/AddTicketTypeField/ [post]
+func (h *TicketHandler) AddTicketTypeFieldHandler(c *gin.Context) {
+	var req dto.TicketTypeFieldRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if err := req.Validate(); err != nil {
+		appErr := errx.Respond(errx.ErrBadRequest, err)
+		c.JSON(appErr.HTTPStatus, appErr)
+		return
+	}
+
+	claims, err := authClaims(c)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+	if !requireStaff(c, h.RolesRelationRepo, claims.UserID) {
+		return
+	}
+
+	exists, err := h.TicketTypeRepo.IsTicketTypeExits(c.Request.Context(), req.TicketTypeID)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+	if !exists {
+		err := errx.Respond(errx.ErrTicketTypeNotFound, errors.New("ticket type not found"))
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	param := ticket_type_fields.AddTicketTypeFieldParams{
+		TicketTypeID: req.TicketTypeID,
+		Name:         req.Name,
+		Label:        req.Label,
+		FieldType:    req.Type,
+		Pattern:      sql.NullString{String: req.Pattern, Valid: req.Pattern != ""},
+		SortOrder:    req.SortOrder,
+	}
+	if req.Required {
+		param.Required = 1
+	}
+	if len(req.Options) > 0 {
+		options, _ := json.Marshal(req.Options)
+		param.Options = sql.NullString{String: string(options), Valid: true}
+	}
+
+	id, err := h.TicketTypeFieldRepo.AddTicketTypeField(c.Request.Context(), param)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.IDResponse[int64]{ID: id})
+}
+
+// DeleteTicketTypeFieldHandler handles POST /tickets/DeleteTicketTypeField/
+// @Summary Delete a custom field of a ticket type
+// @Description New tickets no longer carry the field, values stored on existing tickets are kept. Only staff may do this
+// @Tags Ticket
+// @Accept json
+// @Produce json
+// @Param request body dto.IDRequest[int64] true "Field ID"
+// @Success 204
+// @Failure 400 {object} errx.APIError
+// @Failure 403 {object} errx.APIError
+// @Failure 404 {object} errx.APIError
+// @Failure 500 {object} errx.APIError
+// @Router /tickets/DeleteTicketTypeField/ [post]
+func (h *TicketHandler) DeleteTicketTypeFieldHandler(c *gin.Context) {
+	var req dto.IDRequest[int64]
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	claims, err := authClaims(c)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+	if !requireStaff(c, h.RolesRelationRepo, claims.UserID) {
+		return
+	}
+
+	if err := h.TicketTypeFieldRepo.DeleteTicketTypeField(c.Request.Context(), req.ID); err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
 // GetAllActiveTicketStatusesHandler handles GET /tickets/GetAllActiveTicketStatuses/
 // @Summary Get all active ticket statuses
 // @Description Returns a list of all active ticket statuses
@@ -311,17 +564,674 @@ func (h *TicketHandler) CloseTicketHandler(c *gin.Context) {
 		return
 	}
 
+	claims, err := authClaims(c)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
 	close, err := h.TicketStatusRepo.GetCloseStatus(c.Request.Context())
 	if err != nil {
 		c.JSON(err.HTTPStatus, err)
 		return
 	}
-	ticket, err := h.TicketRepo.SetTicketStatus(c.Request.Context(), req.ID, close.ID)
+	ticket, err := h.TicketRepo.SetTicketStatus(c.Request.Context(), req.ID, claims.UserID, close.ID)
 
 	if err != nil {
 		c.JSON(err.HTTPStatus, err)
 		return
 	}
 
+	sla, err := h.TicketRepo.SyncSLAWithStatus(c.Request.Context(), req.ID, close.Kind)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+	ticket.SLA = dto.ToTicketSLADTO(sla)
+
+	publishTicketEvent(c, h.Stream, dto.TicketStreamEvent{
+		Type:           dto.TicketStreamEventStatus,
+		TicketID:       ticket.ID,
+		ActorID:        claims.UserID,
+		TicketStatusID: close.ID,
+	})
+
 	c.JSON(http.StatusOK, ticket)
 }
+
+// AssignTicketHandler handles POST /tickets/AssignTicket/
+// @Summary Assign a ticket to an agent
+// @Description Assigns (or reassigns) a ticket to an agent of the ticket's department. Only staff may do this
+// @Tags Ticket
+// @Accept json
+// @Produce json
+// @Param request body dto.TicketAssignRequest true "Ticket and assignee IDs"
+// @Success 200 {object} dto.TicketResponse
+// @Failure 400 {object} errx.APIError
+// @Failure 403 {object} errx.APIError
+// @Failure 404 {object} errx.APIError
+// @Failure 422 {object} errx.APIError
+// @Failure 500 {object} errx.APIError
+// @Router /tickets/AssignTicket/ [post]
+func (h *TicketHandler) AssignTicketHandler(c *gin.Context) {
+	var req dto.TicketAssignRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	claims, err := authClaims(c)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+	if !requireStaff(c, h.RolesRelationRepo, claims.UserID) {
+		return
+	}
+
+	ticket, err := h.TicketRepo.GetTicketByID(c.Request.Context(), req.TicketID, dto.TicketChatOptions{SkipChat: true})
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	// Assignee must belong to the ticket's department
+	assignee, err := h.UserRepo.GetUserByID(c.Request.Context(), req.AssigneeID)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+	if assignee.DepartmentID != ticket.DepartmentID {
+		appErr := errx.Respond(errx.ErrAssigneeNotInDepartment, fmt.Errorf("user %d is not in department %d", assignee.ID, ticket.DepartmentID))
+		c.JSON(appErr.HTTPStatus, appErr)
+		return
+	}
+
+	updated, err := h.TicketRepo.SetTicketAssignee(c.Request.Context(), req.TicketID, claims.UserID, assignee.ID)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	publishTicketEvent(c, h.Stream, dto.TicketStreamEvent{
+		Type:       dto.TicketStreamEventAssignment,
+		TicketID:   updated.ID,
+		ActorID:    claims.UserID,
+		AssigneeID: &updated.AssigneeID,
+	})
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// UnassignTicketHandler handles POST /tickets/UnassignTicket/
+// @Summary Unassign a ticket
+// @Description Removes the current assignee of a ticket and puts it back in the department queue. Only staff may do this
+// @Tags Ticket
+// @Accept json
+// @Produce json
+// @Param request body dto.IDRequest[string] true "Ticket ID"
+// @Success 200 {object} dto.TicketResponse
+// @Failure 400 {object} errx.APIError
+// @Failure 403 {object} errx.APIError
+// @Failure 404 {object} errx.APIError
+// @Failure 500 {object} errx.APIError
+// @Router /tickets/UnassignTicket/ [post]
+func (h *TicketHandler) UnassignTicketHandler(c *gin.Context) {
+	var req dto.IDRequest[string]
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	claims, err := authClaims(c)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+	if !requireStaff(c, h.RolesRelationRepo, claims.UserID) {
+		return
+	}
+
+	updated, err := h.TicketRepo.SetTicketAssignee(c.Request.Context(), req.ID, claims.UserID, 0)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	publishTicketEvent(c, h.Stream, dto.TicketStreamEvent{
+		Type:       dto.TicketStreamEventAssignment,
+		TicketID:   updated.ID,
+		ActorID:    claims.UserID,
+		AssigneeID: &updated.AssigneeID,
+	})
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// SetTicketPriorityHandler handles POST /tickets/SetTicketPriority/
+// @Summary Override the priority of a ticket
+// @Description Replaces the priority resolved when the ticket was created. Only staff may do this
+// @Tags Ticket
+// @Accept json
+// @Produce json
+// @Param request body dto.TicketSetPriorityRequest true "Ticket ID and priority"
+// @Success 200 {object} dto.TicketResponse
+// @Failure 400 {object} errx.APIError
+// @Failure 403 {object} errx.APIError
+// @Failure 404 {object} errx.APIError
+// @Failure 500 {object} errx.APIError
+// @Router /tickets/SetTicketPriority/ [post]
+func (h *TicketHandler) SetTicketPriorityHandler(c *gin.Context) {
+	var req dto.TicketSetPriorityRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	claims, err := authClaims(c)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	if !requireStaff(c, h.RolesRelationRepo, claims.UserID) {
+		return
+	}
+
+	updated, err := h.TicketRepo.SetTicketPriority(c.Request.Context(), req.TicketID, claims.UserID, *req.Priority)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// AddTicketTagsHandler handles POST /tickets/AddTicketTags/
+// @Summary Add tags to a ticket
+// @Description Adds active tags of the ticket's department or global tags to a ticket. Only staff may do this
+// @Tags Ticket
+// @Accept json
+// @Produce json
+// @Param request body dto.TicketTagsRequest true "Ticket ID and tag IDs"
+// @Success 200 {object} dto.TicketResponse
+// @Failure 400 {object} errx.APIError
+// @Failure 403 {object} errx.APIError
+// @Failure 404 {object} errx.APIError
+// @Failure 422 {object} errx.APIError
+// @Failure 500 {object} errx.APIError
+// @Router /tickets/AddTicketTags/ [post]
+func (h *TicketHandler) AddTicketTagsHandler(c *gin.Context) {
+	var req dto.TicketTagsRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	claims, err := authClaims(c)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	if !requireStaff(c, h.RolesRelationRepo, claims.UserID) {
+		return
+	}
+
+	ticket, err := h.TicketRepo.GetTicketByID(c.Request.Context(), req.TicketID, dto.TicketChatOptions{SkipChat: true})
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	if err := h.TagRepo.ValidateTicketTags(c.Request.Context(), req.TagIDs, ticket.DepartmentID); err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	updated, err := h.TicketRepo.AddTicketTags(c.Request.Context(), req.TicketID, claims.UserID, req.TagIDs)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// RemoveTicketTagsHandler handles POST /tickets/RemoveTicketTags/
+// @Summary Remove tags from a ticket
+// @Description Removes tags from a ticket. Only staff may do this
+// @Tags Ticket
+// @Accept json
+// @Produce json
+// @Param request body dto.TicketTagsRequest true "Ticket ID and tag IDs"
+// @Success 200 {object} dto.TicketResponse
+// @Failure 400 {object} errx.APIError
+// @Failure 403 {object} errx.APIError
+// @Failure 404 {object} errx.APIError
+// @Failure 500 {object} errx.APIError
+// @Router /tickets/RemoveTicketTags/ [post]
+func (h *TicketHandler) RemoveTicketTagsHandler(c *gin.Context) {
+	var req dto.TicketTagsRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	claims, err := authClaims(c)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	if !requireStaff(c, h.RolesRelationRepo, claims.UserID) {
+		return
+	}
+
+	updated, err := h.TicketRepo.RemoveTicketTags(c.Request.Context(), req.TicketID, claims.UserID, req.TagIDs)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// ChangeTicketStatusHandler handles POST /tickets/ChangeTicketStatus/
+// @Summary Change the status of a ticket
+// @Description Moves a ticket to another status if the workflow allows the transition for the user's roles
+// @Description Staff may change any ticket, other users only their own
+// @Tags Ticket
+// @Accept json
+// @Produce json
+// @Param request body dto.TicketChangeStatusRequest true "Ticket and target status IDs"
+// @Success 200 {object} dto.TicketResponse
+// @Failure 400 {object} errx.APIError
+// @Failure 403 {object} errx.APIError
+// @Failure 404 {object} errx.APIError
+// @Failure 409 {object} errx.APIError
+// @Failure 500 {object} errx.APIError
+// @Router /tickets/ChangeTicketStatus/ [post]
+func (h *TicketHandler) ChangeTicketStatusHandler(c *gin.Context) {
+	var req dto.TicketChangeStatusRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	claims, err := authClaims(c)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	ticket, err := h.TicketRepo.GetTicketByID(c.Request.Context(), req.TicketID, dto.TicketChatOptions{SkipChat: true})
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	// staff may change any ticket, other users only their own
+	isStaff, err := h.RolesRelationRepo.IsStaff(c.Request.Context(), claims.UserID)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+	if !isStaff && ticket.UserID != claims.UserID {
+		appErr := errx.Respond(errx.ErrTicketNotFound, errors.New("user did not create this ticket"))
+		c.JSON(appErr.HTTPStatus, appErr)
+		return
+	}
+
+	// target status must exist and be active
+	targetStatus, err := h.TicketStatusRepo.GetActiveTicketStatusByID(c.Request.Context(), req.TicketStatusID)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	roleIDs, err := h.RolesRelationRepo.GetUserRoleIDs(c.Request.Context(), claims.UserID)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	if err := h.TicketStatusRepo.ValidateTransition(c.Request.Context(), ticket.TicketStatusID, req.TicketStatusID, roleIDs); err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	updated, err := h.TicketRepo.ChangeTicketStatus(c.Request.Context(), req.TicketID, claims.UserID, ticket.TicketStatusID, req.TicketStatusID)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	sla, err := h.TicketRepo.SyncSLAWithStatus(c.Request.Context(), req.TicketID, targetStatus.Kind)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+	updated.SLA = dto.ToTicketSLADTO(sla)
+
+	publishTicketEvent(c, h.Stream, dto.TicketStreamEvent{
+		Type:           dto.TicketStreamEventStatus,
+		TicketID:       updated.ID,
+		ActorID:        claims.UserID,
+		TicketStatusID: updated.TicketStatusID,
+	})
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// GetTicketHistoryHandler handles GET /tickets/:id/History/
+// @Summary Get the change history of a ticket
+// @Description Returns every recorded change of a ticket (who, when, field, old and new value), oldest first
+// @Description Staff may read the history of any ticket, other users only of their own, without the events of internal notes
+// @Tags Ticket
+// @Produce json
+// @Param id path string true "Ticket ID"
+// @Success 200 {array} dto.TicketEventDTO
+// @Failure 400 {object} errx.APIError
+// @Failure 401 {object} errx.APIError
+// @Failure 404 {object} errx.APIError
+// @Failure 500 {object} errx.APIError
+// @Router /tickets/{id}/History/ [get]
+func (h *TicketHandler) GetTicketHistoryHandler(c *gin.Context) {
+	ticketID := c.Param("id")
+
+	// unknown tickets and tickets of other users both answer 404
+	reader, err := h.readers.authorize(c, ticketID, "", "")
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	history, err := h.TicketHistoryRepo.GetTicketHistory(c.Request.Context(), ticketID, reader.IsStaff)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// BulkUpdateTicketsHandler handles POST /tickets/BulkUpdate/
+// @Summary Apply an action to many tickets
+// @Description Changes the status, department, assignee or tags of many tickets, or closes them.
+// @Description Tickets are given by ID or by a list filter. Only staff may do this.
+// @Tags Ticket
+// @Accept json
+// @Produce json
+// @Param request body dto.TicketBulkRequest true "Tickets and action"
+// @Success 200 {object} dto.TicketBulkResponse
+// @Failure 400 {object} errx.APIError
+// @Failure 403 {object} errx.APIError
+// @Failure 404 {object} errx.APIError
+// @Failure 500 {object} errx.APIError
+// @Router /tickets/BulkUpdate/ [post]
+func (h *TicketHandler) BulkUpdateTicketsHandler(c *gin.Context) {
+	var req dto.TicketBulkRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if err := req.Validate(); err != nil {
+		appErr := errx.Respond(errx.ErrBadRequest, err)
+		c.JSON(appErr.HTTPStatus, appErr)
+		return
+	}
+
+	claims, err := authClaims(c)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+	if !requireStaff(c, h.RolesRelationRepo, claims.UserID) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	maxBulkSize := config.Get().TicketConfig.MaxBulkSize
+
+	// Resolve the tickets
+	var ticketIDs []string
+	if req.Filter != nil {
+		// "my queue" means the tickets assigned to the current user
+		if req.Filter.MyQueue {
+			req.Filter.AssigneeID = claims.UserID
+		}
+		req.Filter.ReaderID = claims.UserID
+		req.Filter.ReaderIsStaff = true
+
+		// one more than allowed tells whether the filter matches too many tickets
+		ticketIDs, err = h.TicketRepo.FindTicketIDs(ctx, *req.Filter, maxBulkSize+1)
+		if err != nil {
+			c.JSON(err.HTTPStatus, err)
+			return
+		}
+	} else if len(req.TicketIDs) <= maxBulkSize {
+		for _, id := range req.TicketIDs {
+			if !slices.Contains(ticketIDs, id) {
+				ticketIDs = append(ticketIDs, id)
+			}
+		}
+	} else {
+		ticketIDs = req.TicketIDs
+	}
+	if len(ticketIDs) > maxBulkSize {
+		appErr := errx.Respond(errx.ErrBulkLimitExceeded, fmt.Errorf("%d tickets requested, at most %d allowed", len(ticketIDs), maxBulkSize))
+		c.JSON(appErr.HTTPStatus, appErr)
+		return
+	}
+
+	tickets, err := h.TicketRepo.GetTicketsByIDs(ctx, ticketIDs)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	failed := make(map[string]*errx.APIError)
+	var found []*model.Ticket
+	for _, id := range ticketIDs {
+		if ticket, ok := tickets[id]; ok {
+			found = append(found, ticket)
+		} else {
+			failed[id] = errx.Respond(errx.ErrTicketNotFound, errors.New("ticket not found"))
+		}
+	}
+
+	// Apply the action
+	var actionFailed map[string]*errx.APIError
+	switch req.Action {
+	case dto.TicketBulkActionChangeStatus, dto.TicketBulkActionClose:
+		actionFailed, err = h.bulkChangeStatus(c, claims.UserID, req, found)
+	case dto.TicketBulkActionChangeDepartment:
+		actionFailed, err = h.bulkChangeDepartment(c, claims.UserID, req, found)
+	case dto.TicketBulkActionAssign:
+		actionFailed, err = h.bulkAssign(c, claims.UserID, req, found)
+	case dto.TicketBulkActionTag:
+		actionFailed, err = h.bulkTag(c, claims.UserID, req, found)
+	}
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+	maps.Copy(failed, actionFailed)
+
+	resp := dto.TicketBulkResponse{Results: make([]dto.TicketBulkResult, len(ticketIDs))}
+	for i, id := range ticketIDs {
+		resp.Results[i] = dto.TicketBulkResult{TicketID: id, Success: failed[id] == nil, Error: failed[id]}
+		if failed[id] == nil {
+			resp.Succeeded++
+		} else {
+			resp.Failed++
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// bulkChangeStatus moves tickets to the requested status (or the close status) and updates their SLA.
+// changeStatus follows the workflow for the user's roles, close is always allowed like CloseTicketHandler.
+func (h *TicketHandler) bulkChangeStatus(c *gin.Context, actorID int64, req dto.TicketBulkRequest, tickets []*model.Ticket) (map[string]*errx.APIError, *errx.APIError) {
+	ctx := c.Request.Context()
+
+	var targetStatus *ticket_statuses.TicketStatus
+	var roleIDs []int64
+	var err *errx.APIError
+	if req.Action == dto.TicketBulkActionClose {
+		targetStatus, err = h.TicketStatusRepo.GetCloseStatus(ctx)
+	} else {
+		targetStatus, err = h.TicketStatusRepo.GetActiveTicketStatusByID(ctx, req.TicketStatusID)
+		if err == nil {
+			roleIDs, err = h.RolesRelationRepo.GetUserRoleIDs(ctx, actorID)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	failed := make(map[string]*errx.APIError)
+	transitionErrs := make(map[int64]*errx.APIError) // validation result per current status
+	now := time.Now()
+	var changes []repository.TicketFieldChange
+	for _, ticket := range tickets {
+		if req.Action == dto.TicketBulkActionChangeStatus {
+			transitionErr, validated := transitionErrs[ticket.TicketStatusID]
+			if !validated {
+				transitionErr = h.TicketStatusRepo.ValidateTransition(ctx, ticket.TicketStatusID, targetStatus.ID, roleIDs)
+				transitionErrs[ticket.TicketStatusID] = transitionErr
+			}
+			if transitionErr != nil {
+				failed[ticket.ID] = transitionErr
+				continue
+			}
+		}
+
+		change := repository.TicketFieldChange{TicketID: ticket.ID, OldValue: ticket.TicketStatusID}
+		if ticket.SLA != nil {
+			oldSLA := *ticket.SLA
+			ticket.SLA.ApplyStatusKind(targetStatus.Kind, now)
+			change.OldSLA, change.SLA = &oldSLA, ticket.SLA
+		}
+		changes = append(changes, change)
+	}
+
+	maps.Copy(failed, h.TicketRepo.BulkSetTicketField(ctx, actorID, "ticketStatusId", targetStatus.ID, changes))
+
+	for _, change := range changes {
+		if failed[change.TicketID] == nil {
+			publishTicketEvent(c, h.Stream, dto.TicketStreamEvent{
+				Type:           dto.TicketStreamEventStatus,
+				TicketID:       change.TicketID,
+				ActorID:        actorID,
+				TicketStatusID: targetStatus.ID,
+			})
+		}
+	}
+	return failed, nil
+}
+
+// bulkChangeDepartment moves tickets to another department. Tickets assigned to an agent
+// of another department stay where they are, like bulkAssign refuses such assignees.
+func (h *TicketHandler) bulkChangeDepartment(c *gin.Context, actorID int64, req dto.TicketBulkRequest, tickets []*model.Ticket) (map[string]*errx.APIError, *errx.APIError) {
+	ctx := c.Request.Context()
+
+	exists, err := h.DepartmentRepo.IsDepartmentExits(ctx, req.DepartmentID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errx.Respond(errx.ErrDepartmentNotFound, errors.New("department not found"))
+	}
+
+	var assigneeIDs []int64
+	for _, ticket := range tickets {
+		if ticket.AssigneeID != 0 && !slices.Contains(assigneeIDs, ticket.AssigneeID) {
+			assigneeIDs = append(assigneeIDs, ticket.AssigneeID)
+		}
+	}
+	assigneeDepartments := make(map[int64]int64, len(assigneeIDs))
+	if len(assigneeIDs) > 0 {
+		assignees, err := h.UserRepo.GetUsersByIDs(ctx, assigneeIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, assignee := range assignees {
+			assigneeDepartments[assignee.ID] = assignee.DepartmentID
+		}
+	}
+
+	failed := make(map[string]*errx.APIError)
+	var changes []repository.TicketFieldChange
+	for _, ticket := range tickets {
+		if ticket.AssigneeID != 0 && assigneeDepartments[ticket.AssigneeID] != req.DepartmentID {
+			failed[ticket.ID] = errx.Respond(errx.ErrAssigneeNotInDepartment, fmt.Errorf("assignee %d is not in department %d", ticket.AssigneeID, req.DepartmentID))
+			continue
+		}
+		changes = append(changes, repository.TicketFieldChange{TicketID: ticket.ID, OldValue: ticket.DepartmentID})
+	}
+
+	maps.Copy(failed, h.TicketRepo.BulkSetTicketField(ctx, actorID, "departmentId", req.DepartmentID, changes))
+	return failed, nil
+}
+
+// bulkAssign assigns tickets to an agent of their department, or unassigns them
+func (h *TicketHandler) bulkAssign(c *gin.Context, actorID int64, req dto.TicketBulkRequest, tickets []*model.Ticket) (map[string]*errx.APIError, *errx.APIError) {
+	ctx := c.Request.Context()
+
+	var assignee *dto.UserDTO
+	if req.AssigneeID != 0 {
+		var err *errx.APIError
+		if assignee, err = h.UserRepo.GetUserByID(ctx, req.AssigneeID); err != nil {
+			return nil, err
+		}
+	}
+
+	failed := make(map[string]*errx.APIError)
+	var changes []repository.TicketFieldChange
+	for _, ticket := range tickets {
+		if assignee != nil && assignee.DepartmentID != ticket.DepartmentID {
+			failed[ticket.ID] = errx.Respond(errx.ErrAssigneeNotInDepartment, fmt.Errorf("user %d is not in department %d", assignee.ID, ticket.DepartmentID))
+			continue
+		}
+		changes = append(changes, repository.TicketFieldChange{TicketID: ticket.ID, OldValue: ticket.AssigneeID})
+	}
+
+	maps.Copy(failed, h.TicketRepo.BulkSetTicketField(ctx, actorID, "assigneeId", req.AssigneeID, changes))
+
+	for _, change := range changes {
+		if failed[change.TicketID] == nil {
+			publishTicketEvent(c, h.Stream, dto.TicketStreamEvent{
+				Type:       dto.TicketStreamEventAssignment,
+				TicketID:   change.TicketID,
+				ActorID:    actorID,
+				AssigneeID: &req.AssigneeID,
+			})
+		}
+	}
+	return failed, nil
+}
+
+// bulkTag adds tags to tickets whose department may use all of them
+func (h *TicketHandler) bulkTag(c *gin.Context, actorID int64, req dto.TicketBulkRequest, tickets []*model.Ticket) (map[string]*errx.APIError, *errx.APIError) {
+	ctx := c.Request.Context()
+
+	failed := make(map[string]*errx.APIError)
+	tagErrs := make(map[int64]*errx.APIError) // validation result per department
+	var tagged []*model.Ticket
+	for _, ticket := range tickets {
+		tagErr, validated := tagErrs[ticket.DepartmentID]
+		if !validated {
+			tagErr = h.TagRepo.ValidateTicketTags(ctx, req.TagIDs, ticket.DepartmentID)
+			if tagErr != nil && tagErr.HTTPStatus == http.StatusInternalServerError {
+				return nil, tagErr
+			}
+			tagErrs[ticket.DepartmentID] = tagErr
+		}
+		if tagErr != nil {
+			failed[ticket.ID] = tagErr
+			continue
+		}
+		tagged = append(tagged, ticket)
+	}
+
+	if err := h.TicketRepo.BulkAddTicketTags(ctx, actorID, tagged, req.TagIDs); err != nil {
+		return nil, err
+	}
+	return failed, nil
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"ticket-api/internal/dto"
+	"ticket-api/internal/errx"
+	"ticket-api/internal/repository"
+	"ticket-api/internal/services/cookie"
+	"ticket-api/internal/services/token"
+	"ticket-api/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ticketReader is a requester authorized to read a ticket
+type ticketReader struct {
+	UserID    int64  // zero for anonymous requesters
+	IsStaff   bool   // staff may read internal notes
+	TrackCode string // set for anonymous requesters
+}
+
+// String describes the reader for logs
+func (r *ticketReader) String() string {
+	switch {
+	case r.TrackCode != "":
+		return "track code " + r.TrackCode
+	case r.IsStaff:
+		return fmt.Sprintf("staff user %d", r.UserID)
+	default:
+		return fmt.Sprintf("user %d", r.UserID)
+	}
+}
+
+// ticketReaderAuth authorizes readers of a ticket on routes open to anonymous requesters
+type ticketReaderAuth struct {
+	ticketRepo        *repository.TicketRepository
+	userRepo          *repository.UsersRepository
+	rolesRelationRepo *repository.RolesRelationsRepository
+	tokenService      *token.TokenService
+}
+
+// authorize checks that the requester may read the ticket. Anonymous requesters give the
+// trackCode and username of the ticket; logged-in users are authorized by their auth cookie,
+// staff may read any ticket and other users only their own. Unknown tickets and tickets of
+// other users both answer ErrTicketNotFound so IDs cannot be probed.
+func (a *ticketReaderAuth) authorize(c *gin.Context, ticketID, trackCode, username string) (*ticketReader, *errx.APIError) {
+	ctx := c.Request.Context()
+
+	if trackCode != "" {
+		if _, parseErr := util.ParsTrackCode(trackCode); parseErr != nil {
+			return nil, errx.Respond(errx.ErrBadRequest, parseErr)
+		}
+
+		user, err := a.userRepo.GetUserByUsername(ctx, username)
+		if err != nil {
+			if err.Err.Code == errx.ErrUserNotFound {
+				err = errx.Respond(errx.ErrTicketNotFound, errors.New("username not found"))
+			}
+			return nil, err
+		}
+
+		ticket, err := a.ticketRepo.GetTicketByTrackCode(ctx, trackCode, dto.TicketChatOptions{SkipChat: true})
+		if err != nil {
+			return nil, err
+		}
+		if ticket.UserID != user.ID || !strings.EqualFold(ticket.ID, ticketID) {
+			return nil, errx.Respond(errx.ErrTicketNotFound, errors.New("track code does not match this ticket and username"))
+		}
+		return &ticketReader{UserID: user.ID, TrackCode: trackCode}, nil
+	}
+
+	authToken, cookieErr := cookie.NewAuthCookieService().Get(c)
+	if cookieErr != nil {
+		return nil, errx.Respond(errx.ErrUnauthorized, cookieErr)
+	}
+	claims, err := a.tokenService.ParseAuthToken(authToken)
+	if err != nil {
+		return nil, err
+	}
+
+	ticket, err := a.ticketRepo.GetTicketByID(ctx, ticketID, dto.TicketChatOptions{SkipChat: true})
+	if err != nil {
+		return nil, err
+	}
+
+	isStaff, err := a.rolesRelationRepo.IsStaff(ctx, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !isStaff && ticket.UserID != claims.UserID {
+		return nil, errx.Respond(errx.ErrTicketNotFound, errors.New("user did not create this ticket"))
+	}
+	return &ticketReader{UserID: claims.UserID, IsStaff: isStaff}, nil
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"ticket-api/internal/config"
+	"ticket-api/internal/errx"
+	"ticket-api/internal/repository"
+	"ticket-api/internal/services/stream"
+	"ticket-api/internal/services/token"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TicketStreamHandler struct {
+	TicketRepo        *repository.TicketRepository
+	UserRepo          *repository.UsersRepository
+	RolesRelationRepo *repository.RolesRelationsRepository
+	Stream            *stream.StreamService
+	TokenService      *token.TokenService
+}
+
+// NewTicketStreamHandler constructor
+func NewTicketStreamHandler(
+	ticketRepo *repository.TicketRepository,
+	userRepo *repository.UsersRepository,
+	rolesRelationRepo *repository.RolesRelationsRepository,
+	streamService *stream.StreamService,
+	tokenService *token.TokenService,
+) *TicketStreamHandler {
+	return &TicketStreamHandler{
+		TicketRepo:        ticketRepo,
+		UserRepo:          userRepo,
+		RolesRelationRepo: rolesRelationRepo,
+		Stream:            streamService,
+		TokenService:      tokenService,
+	}
+}
+
+// StreamTicketHandler handles GET /tickets/:id/Stream/
+// @Summary Stream live updates of a ticket
+// @Description Opens a Server-Sent Events stream of new, edited and deleted chat messages, status changes and assignments of a ticket.
+// @Description Logged-in users are authorized by their auth cookie; staff may follow any ticket, other users only their own.
+// @Description Anonymous requesters pass the trackCode and username of the ticket as query parameters. Internal notes are only streamed to staff.
+// @Tags Ticket
+// @Produce text/event-stream
+// @Param id path string true "Ticket ID"
+// @Param trackCode query string false "Track code of the ticket (anonymous requesters)"
+// @Param username query string false "Username of the ticket creator (anonymous requesters)"
+// @Success 200 {object} dto.TicketStreamEvent
+// @Failure 400 {object} errx.APIError
+// @Failure 401 {object} errx.APIError
+// @Failure 404 {object} errx.APIError
+// @Failure 503 {object} errx.APIError
+// @Router /tickets/{id}/Stream/ [get]
+func (h *TicketStreamHandler) StreamTicketHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	canSeeInternal, err := h.authorizeStream(c)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	events, subErr := h.Stream.Subscribe(ctx, c.Param("id"))
+	if subErr != nil {
+		appErr := errx.Respond(errx.ErrServiceUnavailable, subErr)
+		c.JSON(appErr.HTTPStatus, appErr)
+		return
+	}
+
+	// A stream outlives the write timeout of the server
+	if deadlineErr := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); deadlineErr != nil {
+		appErr := errx.Respond(errx.ErrInternalServerError, deadlineErr)
+		c.JSON(appErr.HTTPStatus, appErr)
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(time.Duration(config.Get().TicketConfig.StreamHeartbeatSeconds) * time.Second)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if event.IsInternal() && !canSeeInternal {
+				continue
+			}
+			c.SSEvent(event.Type, event)
+			c.Writer.Flush()
+		case <-heartbeat.C:
+			// Comments keep proxies from closing an idle connection
+			if _, writeErr := fmt.Fprint(c.Writer, ": ping\n\n"); writeErr != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+// authorizeStream checks that the requester may follow the ticket of the request and
+// reports whether internal notes may be streamed to them
+func (h *TicketStreamHandler) authorizeStream(c *gin.Context) (bool, *errx.APIError) {
+	readers := &ticketReaderAuth{
+		ticketRepo:        h.TicketRepo,
+		userRepo:          h.UserRepo,
+		rolesRelationRepo: h.RolesRelationRepo,
+		tokenService:      h.TokenService,
+	}
+
+	reader, err := readers.authorize(c, c.Param("id"), c.Query("trackCode"), c.Query("username"))
+	if err != nil {
+		return false, err
+	}
+	return reader.IsStaff, nil
+}
@@ -0,0 +1,240 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"ticket-api/internal/dto"
+	"ticket-api/internal/errx"
+	"ticket-api/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TicketViewHandler handles saved ticket view HTTP requests
+type TicketViewHandler struct {
+	TicketViewRepo    *repository.TicketViewsRepository
+	TicketRepo        *repository.TicketRepository
+	UserRepo          *repository.UsersRepository
+	RolesRelationRepo *repository.RolesRelationsRepository
+}
+
+// NewTicketViewHandler creates a new TicketViewHandler instance
+func NewTicketViewHandler(
+	ticketViewRepo *repository.TicketViewsRepository,
+	ticketRepo *repository.TicketRepository,
+	userRepo *repository.UsersRepository,
+	rolesRelationRepo *repository.RolesRelationsRepository,
+) *TicketViewHandler {
+	return &TicketViewHandler{
+		TicketViewRepo:    ticketViewRepo,
+		TicketRepo:        ticketRepo,
+		UserRepo:          userRepo,
+		RolesRelationRepo: rolesRelationRepo,
+	}
+}
+
+// currentUser returns the authenticated user, responding with the error if it fails
+func (h *TicketViewHandler) currentUser(c *gin.Context) (*dto.UserDTO, bool) {
+	claims, err := authClaims(c)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return nil, false
+	}
+
+	user, err := h.UserRepo.GetUserByID(c.Request.Context(), claims.UserID)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return nil, false
+	}
+	return user, true
+}
+
+// CreateViewHandler handles POST /tickets/views/CreateView/
+// @Summary Save a ticket view
+// @Description Saves a named ticket filter and sort combination, optionally shared with the user's department
+// @Tags TicketView
+// @Accept json
+// @Produce json
+// @Param request body dto.TicketViewRequest true "View data"
+// @Success 201 {object} dto.IDResponse[int64]
+// @Failure 400 {object} errx.APIError
+// @Failure 500 {object} errx.APIError
+// @Router /tickets/views/CreateView/ [post]
+func (h *TicketViewHandler) CreateViewHandler(c *gin.Context) {
+	var req dto.TicketViewRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	id, err := h.TicketViewRepo.AddTicketView(c.Request.Context(), user.ID, user.DepartmentID, req)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.IDResponse[int64]{ID: id})
+}
+
+// UpdateViewHandler handles POST /tickets/views/UpdateView/
+// @Summary Update a ticket view
+// @Description Replaces the title, query and sharing of a view owned by the user
+// @Tags TicketView
+// @Accept json
+// @Produce json
+// @Param request body dto.TicketViewUpdateRequest true "View data"
+// @Success 200 {object} dto.TicketViewDTO
+// @Failure 400 {object} errx.APIError
+// @Failure 404 {object} errx.APIError
+// @Failure 500 {object} errx.APIError
+// @Router /tickets/views/UpdateView/ [post]
+func (h *TicketViewHandler) UpdateViewHandler(c *gin.Context) {
+	var req dto.TicketViewUpdateRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	if err := h.TicketViewRepo.UpdateTicketView(c.Request.Context(), req.ID, user.ID, user.DepartmentID, req.TicketViewRequest); err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	view, err := h.TicketViewRepo.GetTicketViewByID(c.Request.Context(), req.ID)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, view)
+}
+
+// DeleteViewHandler handles POST /tickets/views/DeleteView/
+// @Summary Delete a ticket view
+// @Description Deletes a view owned by the user
+// @Tags TicketView
+// @Accept json
+// @Produce json
+// @Param request body dto.IDRequest[int64] true "View ID"
+// @Success 204
+// @Failure 400 {object} errx.APIError
+// @Failure 404 {object} errx.APIError
+// @Failure 500 {object} errx.APIError
+// @Router /tickets/views/DeleteView/ [post]
+func (h *TicketViewHandler) DeleteViewHandler(c *gin.Context) {
+	var req dto.IDRequest[int64]
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	claims, err := authClaims(c)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	if err := h.TicketViewRepo.DeleteTicketView(c.Request.Context(), req.ID, claims.UserID); err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetViewsHandler handles GET /tickets/views/GetViews/
+// @Summary List ticket views
+// @Description Returns the views of the user and the views shared with the user's department
+// @Tags TicketView
+// @Produce json
+// @Success 200 {array} dto.TicketViewDTO
+// @Failure 500 {object} errx.APIError
+// @Router /tickets/views/GetViews/ [get]
+func (h *TicketViewHandler) GetViewsHandler(c *gin.Context) {
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	views, err := h.TicketViewRepo.GetVisibleTicketViews(c.Request.Context(), user.ID, user.DepartmentID)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, views)
+}
+
+// RunViewHandler handles POST /tickets/views/RunView/
+// @Summary Run a ticket view
+// @Description Lists the tickets matching a saved view, with the given paging
+// @Tags TicketView
+// @Accept json
+// @Produce json
+// @Param request body dto.TicketViewRunRequest true "View ID and paging options"
+// @Success 200 {object} dto.PagingResponse[dto.TicketResponse]
+// @Failure 400 {object} errx.APIError
+// @Failure 404 {object} errx.APIError
+// @Failure 500 {object} errx.APIError
+// @Router /tickets/views/RunView/ [post]
+func (h *TicketViewHandler) RunViewHandler(c *gin.Context) {
+	var req dto.TicketViewRunRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	view, err := h.TicketViewRepo.GetTicketViewByID(c.Request.Context(), req.ID)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+	if !view.IsVisibleTo(user.ID, user.DepartmentID) {
+		appErr := errx.Respond(errx.ErrTicketViewNotFound, errors.New("view is not visible to user"))
+		c.JSON(appErr.HTTPStatus, appErr)
+		return
+	}
+
+	query := view.Query
+	query.Page = req.Page
+	query.Cursor = req.Cursor
+	if req.PageSize != 0 {
+		query.PageSize = req.PageSize
+	}
+
+	// "my queue" and unread counts mean the user running the view, not its owner
+	if query.MyQueue {
+		query.AssigneeID = user.ID
+	}
+
+	canSeeInternal, err := canSeeInternalMessages(c, h.RolesRelationRepo)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+	query.ReaderID = user.ID
+	query.ReaderIsStaff = canSeeInternal
+
+	tickets, err := h.TicketRepo.GetTickets(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(err.HTTPStatus, err)
+		return
+	}
+
+	if !canSeeInternal {
+		stripInternalMessages(tickets.Items)
+	}
+
+	c.JSON(http.StatusOK, tickets)
+}
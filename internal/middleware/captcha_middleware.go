@@ -21,9 +21,10 @@ func CaptchaMiddleware(tokenService *token.TokenService) gin.HandlerFunc {
 		authToken, errCookie := authService.Get(c)
 		if errCookie == nil {
 			// Validate auth token
-			_, err := tokenService.ParseAuthToken(authToken)
+			user, err := tokenService.ParseAuthToken(authToken)
 			if err == nil {
 				// Auth token is valid, skip captcha
+				c.Set("user", user)
 				c.Next()
 				return
 			}
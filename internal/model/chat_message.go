@@ -5,9 +5,38 @@ import "time"
 // ChatMessage represents a single message in a ticket chat
 type ChatMessage struct {
 	ID          string    `bson:"_id"`
+	TicketID    string    `bson:"ticketId"`    // شناسه تیکت
 	SenderID    int64     `bson:"senderId"`    // شناسه فرستنده
 	Message     string    `bson:"message"`     // متن بدنه
 	Attachments []string  `bson:"attachments"` // پیوست آرایه آدرس فایل
+	Internal    bool      `bson:"internal"`    // یادداشت داخلی، فقط برای کارشناسان
 	CreatedAt   time.Time `bson:"createdAt"`   // زمان ارسال
 	UpdatedAt   time.Time `bson:"updatedAt"`   // زمان بروزرسانی
+
+	Revisions []ChatRevision `bson:"revisions,omitempty"` // متن‌های قبلی پیام، قدیمی‌ترین اول
+	DeletedAt *time.Time     `bson:"deletedAt,omitempty"` // زمان حذف (حذف نرم)
+}
+
+// ChatRevision is a previous text of an edited chat message
+type ChatRevision struct {
+	Message  string    `bson:"message"`  // متن قبل از ویرایش
+	EditedAt time.Time `bson:"editedAt"` // زمان ویرایش
+}
+
+// IsDeleted reports whether the message was deleted by its sender
+func (m *ChatMessage) IsDeleted() bool {
+	return m.DeletedAt != nil
+}
+
+// ChatMessageRef points at a chat message from its ticket, so ticket lists can tell
+// whether a ticket has news without reading its chat
+type ChatMessageRef struct {
+	ID        string    `bson:"_id"`
+	SenderID  int64     `bson:"senderId"`
+	CreatedAt time.Time `bson:"createdAt"`
+}
+
+// Ref returns a reference to the message
+func (m *ChatMessage) Ref() *ChatMessageRef {
+	return &ChatMessageRef{ID: m.ID, SenderID: m.SenderID, CreatedAt: m.CreatedAt}
 }
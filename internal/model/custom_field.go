@@ -0,0 +1,131 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+)
+
+// Custom field types of a ticket type
+const (
+	CustomFieldText   = "text"   // free text, optionally matching a pattern
+	CustomFieldNumber = "number" // any JSON number
+	CustomFieldEnum   = "enum"   // one of the options of the field
+	CustomFieldDate   = "date"   // calendar date, stored as YYYY-MM-DD
+)
+
+// CustomFieldTypes lists every valid custom field type
+var CustomFieldTypes = []string{
+	CustomFieldText,
+	CustomFieldNumber,
+	CustomFieldEnum,
+	CustomFieldDate,
+}
+
+// CustomFieldDateLayout is the format of date custom fields. Dates in this format
+// compare correctly as strings, so range filters work on the stored value.
+const CustomFieldDateLayout = "2006-01-02"
+
+// CustomFieldNamePattern restricts field names to identifiers, so a name can be used
+// as a key of the ticket document without escaping.
+var CustomFieldNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]{0,49}$`)
+
+// CustomFieldOptions decodes the allowed values of an enum field
+func CustomFieldOptions(field *TicketTypeField) ([]string, error) {
+	if !field.Options.Valid {
+		return nil, nil
+	}
+	var options []string
+	err := json.Unmarshal([]byte(field.Options.String), &options)
+	return options, err
+}
+
+// ValidateCustomFields checks values against the field definitions of a ticket type and
+// returns them in their stored form. Unknown fields and missing required fields are rejected.
+func ValidateCustomFields(fields []TicketTypeField, values map[string]any) (map[string]any, error) {
+	result := make(map[string]any, len(values))
+	for i := range fields {
+		field := &fields[i]
+		value, ok := values[field.Name]
+		if !ok || value == nil || value == "" {
+			if field.Required != 0 {
+				return nil, fmt.Errorf("field %q is required", field.Name)
+			}
+			continue
+		}
+
+		normalized, err := normalizeCustomField(field, value)
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %w", field.Name, err)
+		}
+		result[field.Name] = normalized
+	}
+
+	for name := range values {
+		if !slices.ContainsFunc(fields, func(f TicketTypeField) bool { return f.Name == name }) {
+			return nil, fmt.Errorf("unknown field %q", name)
+		}
+	}
+
+	if len(result) == 0 {
+		return nil, nil
+	}
+	return result, nil
+}
+
+func normalizeCustomField(field *TicketTypeField, value any) (any, error) {
+	switch field.FieldType {
+	case CustomFieldText:
+		s, ok := value.(string)
+		if !ok {
+			return nil, errors.New("must be a string")
+		}
+		if field.Pattern.Valid {
+			matched, err := regexp.MatchString(field.Pattern.String, s)
+			if err != nil {
+				return nil, err
+			}
+			if !matched {
+				return nil, errors.New("does not match the required pattern")
+			}
+		}
+		return s, nil
+
+	case CustomFieldNumber:
+		n, ok := value.(float64)
+		if !ok {
+			return nil, errors.New("must be a number")
+		}
+		return n, nil
+
+	case CustomFieldEnum:
+		s, ok := value.(string)
+		if !ok {
+			return nil, errors.New("must be a string")
+		}
+		options, err := CustomFieldOptions(field)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(options, s) {
+			return nil, fmt.Errorf("must be one of %v", options)
+		}
+		return s, nil
+
+	case CustomFieldDate:
+		s, ok := value.(string)
+		if !ok {
+			return nil, errors.New("must be a date string")
+		}
+		date, err := time.Parse(CustomFieldDateLayout, s)
+		if err != nil {
+			return nil, fmt.Errorf("must be a date in %s format", CustomFieldDateLayout)
+		}
+		return date.Format(CustomFieldDateLayout), nil
+	}
+
+	return nil, fmt.Errorf("unknown field type %q", field.FieldType)
+}
@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// ReadReceipt records the last chat message of a ticket a participant has read
+type ReadReceipt struct {
+	UserID        int64     `bson:"userId"`        // Participant who read the chat
+	MessageID     string    `bson:"messageId"`     // Last message read
+	MessageSentAt time.Time `bson:"messageSentAt"` // createdAt of that message, later messages are unread
+	ReadAt        time.Time `bson:"readAt"`        // When the participant read it
+	Internal      bool      `bson:"internal"`      // The message is an internal note, hidden from the requester
+}
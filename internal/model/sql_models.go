@@ -7,6 +7,7 @@ import (
 	"ticket-api/internal/db/roles"
 	"ticket-api/internal/db/roles_relations"
 	"ticket-api/internal/db/ticket_statuses"
+	"ticket-api/internal/db/ticket_type_fields"
 	"ticket-api/internal/db/ticket_types"
 	"ticket-api/internal/db/users"
 	"ticket-api/internal/db/version"
@@ -21,4 +22,5 @@ type (
 	TicketType         = ticket_types.TicketType
 	APIHandler         = api_routes.ApiRoute
 	TicketStatus       = ticket_statuses.TicketStatus
+	TicketTypeField    = ticket_type_fields.TicketTypeField
 )
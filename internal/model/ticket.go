@@ -6,15 +6,24 @@ import (
 
 // Ticket is the MongoDB model for tickets
 type Ticket struct {
-	ID              string        `bson:"_id"`             // Unique ticket ID (UUID)
-	UserID          int64         `bson:"userId"`          // ID of the user who created the ticket
-	DepartmentID    int64         `bson:"departmentId"`    // Department of the user
-	TicketTypeID    int64         `bson:"ticketTypeId"`    // Type/category of the ticket
-	TicketStatusID  int64         `bson:"ticketStatusId"`  // Current status (open, closed, etc.)
-	Title           string        `bson:"title"`           // Short descriptive title
-	TrackCode       string        `bson:"trackCode"`       // 8-char code shown to user
-	CreatedAt       time.Time     `bson:"createdAt"`       // Ticket creation timestamp
-	UpdatedAt       time.Time     `bson:"updatedAt"`       // Last update timestamp
-	Chat            []ChatMessage `bson:"chat"`            // Conversation messages for this ticket
-	AttachmentCount int           `bson:"attachmentCount"` // Number of uploaded attachments
+	ID                string          `bson:"_id"`                    // Unique ticket ID (UUID)
+	UserID            int64           `bson:"userId"`                 // ID of the user who created the ticket
+	DepartmentID      int64           `bson:"departmentId"`           // Department of the user
+	TicketTypeID      int64           `bson:"ticketTypeId"`           // Type/category of the ticket
+	TicketStatusID    int64           `bson:"ticketStatusId"`         // Current status (open, closed, etc.)
+	AssigneeID        int64           `bson:"assigneeId"`             // ID of the agent handling the ticket (0 = unassigned)
+	Priority          int64           `bson:"priority"`               // Resolved at creation, may be overridden by staff
+	Tags              []int64         `bson:"tags"`                   // IDs of the tags attached to the ticket
+	CustomFields      map[string]any  `bson:"customFields,omitempty"` // Values of the custom fields of the ticket type
+	Title             string          `bson:"title"`                  // Short descriptive title
+	TrackCode         string          `bson:"trackCode"`              // 8-char code shown to user
+	CreatedAt         time.Time       `bson:"createdAt"`              // Ticket creation timestamp
+	UpdatedAt         time.Time       `bson:"updatedAt"`              // Last update timestamp
+	Chat              []ChatMessage   `bson:"-"`                      // Conversation messages, stored in the chat collection
+	LastMessage       *ChatMessageRef `bson:"lastMessage"`            // Latest message, internal notes included
+	LastPublicMessage *ChatMessageRef `bson:"lastPublicMessage"`      // Latest message the requester can see
+	ReadReceipts      []ReadReceipt   `bson:"readReceipts"`           // Last message read by each participant
+	AttachmentCount   int             `bson:"attachmentCount"`        // Number of uploaded attachments
+	SLA               *TicketSLA      `bson:"sla"`                    // Service level deadlines (nil if no policy applies)
+	SearchText        []string        `bson:"searchText"`             // Normalized title and messages, covered by the text index
 }
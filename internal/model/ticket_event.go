@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// Ticket event actions
+const (
+	TicketEventCreated     = "created"     // ticket was created
+	TicketEventUpdated     = "updated"     // a single field of the ticket changed
+	TicketEventChatMessage = "chatMessage" // a chat message was added
+	TicketEventChatEdited  = "chatEdited"  // a chat message was edited by its sender
+	TicketEventChatDeleted = "chatDeleted" // a chat message was deleted by its sender
+)
+
+// TicketEvent is an append-only history entry of a ticket
+type TicketEvent struct {
+	ID        string    `bson:"_id"`                // Unique event ID (UUID)
+	TicketID  string    `bson:"ticketId"`           // Ticket the event belongs to
+	ActorID   int64     `bson:"actorId"`            // User who made the change
+	Action    string    `bson:"action"`             // created, updated, chatMessage, ...
+	Field     string    `bson:"field,omitempty"`    // Changed field (for updates)
+	OldValue  any       `bson:"oldValue,omitempty"` // Value before the change
+	NewValue  any       `bson:"newValue,omitempty"` // Value after the change
+	CreatedAt time.Time `bson:"createdAt"`          // When the change happened
+}
@@ -0,0 +1,108 @@
+package model
+
+import "time"
+
+// TicketSLA holds the service level deadlines of a ticket
+type TicketSLA struct {
+	PolicyID           int64      `bson:"policyId"`           // SLA policy the deadlines were computed from
+	FirstResponseDueAt time.Time  `bson:"firstResponseDueAt"` // Deadline for the first staff reply
+	ResolveDueAt       time.Time  `bson:"resolveDueAt"`       // Deadline for resolving the ticket
+	FirstRespondedAt   *time.Time `bson:"firstRespondedAt"`   // When the first staff reply was sent
+	ResolvedAt         *time.Time `bson:"resolvedAt"`         // When the ticket was resolved or closed
+	PausedAt           *time.Time `bson:"pausedAt"`           // Set while waiting on the customer
+	Breached           bool       `bson:"breached"`           // A deadline was missed
+}
+
+// NewTicketSLA starts the SLA clock of a ticket at now
+func NewTicketSLA(policyID int64, now time.Time, firstResponse time.Duration, resolve time.Duration) *TicketSLA {
+	return &TicketSLA{
+		PolicyID:           policyID,
+		FirstResponseDueAt: now.Add(firstResponse),
+		ResolveDueAt:       now.Add(resolve),
+	}
+}
+
+// IsBreached reports whether a deadline has been missed at now
+func (s *TicketSLA) IsBreached(now time.Time) bool {
+	if s.Breached {
+		return true
+	}
+	if s.PausedAt != nil {
+		return false
+	}
+	if s.FirstRespondedAt == nil && now.After(s.FirstResponseDueAt) {
+		return true
+	}
+	return s.ResolvedAt == nil && now.After(s.ResolveDueAt)
+}
+
+// Pause stops the SLA clock
+func (s *TicketSLA) Pause(now time.Time) {
+	if s.PausedAt != nil {
+		return
+	}
+	s.Breached = s.IsBreached(now)
+	s.PausedAt = &now
+}
+
+// Resume restarts the SLA clock and moves the open deadlines by the paused time
+func (s *TicketSLA) Resume(now time.Time) {
+	if s.PausedAt == nil {
+		return
+	}
+	paused := now.Sub(*s.PausedAt)
+	if s.FirstRespondedAt == nil {
+		s.FirstResponseDueAt = s.FirstResponseDueAt.Add(paused)
+	}
+	if s.ResolvedAt == nil {
+		s.ResolveDueAt = s.ResolveDueAt.Add(paused)
+	}
+	s.PausedAt = nil
+}
+
+// MarkFirstResponse records the first staff reply
+func (s *TicketSLA) MarkFirstResponse(now time.Time) {
+	if s.FirstRespondedAt != nil {
+		return
+	}
+	s.Breached = s.IsBreached(now)
+	s.FirstRespondedAt = &now
+}
+
+// MarkResolved stops the resolve clock
+func (s *TicketSLA) MarkResolved(now time.Time) {
+	s.Resume(now)
+	if s.ResolvedAt != nil {
+		return
+	}
+	s.Breached = s.IsBreached(now)
+	s.ResolvedAt = &now
+}
+
+// Reopen restarts the resolve clock of a resolved ticket. The resolved time counts like a
+// pause, so the open deadlines move by it.
+func (s *TicketSLA) Reopen(now time.Time) {
+	s.Resume(now)
+	if s.ResolvedAt == nil {
+		return
+	}
+	resolved := now.Sub(*s.ResolvedAt)
+	if s.FirstRespondedAt == nil {
+		s.FirstResponseDueAt = s.FirstResponseDueAt.Add(resolved)
+	}
+	s.ResolveDueAt = s.ResolveDueAt.Add(resolved)
+	s.ResolvedAt = nil
+}
+
+// ApplyStatusKind updates the SLA clock after the ticket moved to a status of the given kind.
+// Pending statuses pause the clock, resolved and closed ones stop it, every other kind runs it.
+func (s *TicketSLA) ApplyStatusKind(kind string, now time.Time) {
+	switch kind {
+	case TicketStatusKindPending:
+		s.Pause(now)
+	case TicketStatusKindResolved, TicketStatusKindClosed:
+		s.MarkResolved(now)
+	default:
+		s.Reopen(now)
+	}
+}
@@ -0,0 +1,173 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTicketSLAClock(t *testing.T) {
+	start := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) time.Time { return start.Add(d) }
+
+	tests := []struct {
+		name              string
+		steps             func(s *TicketSLA)
+		wantFirstResponse time.Duration // due time after start
+		wantResolve       time.Duration
+		wantResolved      bool
+		wantBreached      bool
+	}{
+		{
+			name: "pause and resume move both open deadlines",
+			steps: func(s *TicketSLA) {
+				s.Pause(at(30 * time.Minute))
+				s.Resume(at(90 * time.Minute))
+			},
+			wantFirstResponse: 2 * time.Hour,
+			wantResolve:       5 * time.Hour,
+		},
+		{
+			name: "pause after the first response only moves the resolve deadline",
+			steps: func(s *TicketSLA) {
+				s.MarkFirstResponse(at(10 * time.Minute))
+				s.Pause(at(30 * time.Minute))
+				s.Resume(at(90 * time.Minute))
+			},
+			wantFirstResponse: time.Hour,
+			wantResolve:       5 * time.Hour,
+		},
+		{
+			name: "resolving a paused ticket resumes it first",
+			steps: func(s *TicketSLA) {
+				s.Pause(at(30 * time.Minute))
+				s.MarkResolved(at(90 * time.Minute))
+			},
+			wantFirstResponse: 2 * time.Hour,
+			wantResolve:       5 * time.Hour,
+			wantResolved:      true,
+		},
+		{
+			name: "reopen moves the resolve deadline by the resolved time",
+			steps: func(s *TicketSLA) {
+				s.MarkFirstResponse(at(10 * time.Minute))
+				s.MarkResolved(at(time.Hour))
+				s.Reopen(at(3 * time.Hour))
+			},
+			wantFirstResponse: time.Hour,
+			wantResolve:       6 * time.Hour,
+		},
+		{
+			name: "reopen without a first response moves both deadlines",
+			steps: func(s *TicketSLA) {
+				s.MarkResolved(at(30 * time.Minute))
+				s.Reopen(at(150 * time.Minute))
+			},
+			wantFirstResponse: 3 * time.Hour,
+			wantResolve:       6 * time.Hour,
+		},
+		{
+			name: "pause, resume, resolve and reopen",
+			steps: func(s *TicketSLA) {
+				s.Pause(at(20 * time.Minute))
+				s.Resume(at(50 * time.Minute))
+				s.MarkFirstResponse(at(time.Hour))
+				s.MarkResolved(at(2 * time.Hour))
+				s.Reopen(at(4 * time.Hour))
+			},
+			wantFirstResponse: 90 * time.Minute,
+			wantResolve:       390 * time.Minute,
+		},
+		{
+			name: "status kinds drive the same clock",
+			steps: func(s *TicketSLA) {
+				s.ApplyStatusKind(TicketStatusKindPending, at(30*time.Minute))
+				s.ApplyStatusKind(TicketStatusKindOpen, at(time.Hour))
+				s.ApplyStatusKind(TicketStatusKindResolved, at(2*time.Hour))
+			},
+			wantFirstResponse: 90 * time.Minute,
+			wantResolve:       270 * time.Minute,
+			wantResolved:      true,
+			wantBreached:      true,
+		},
+		{
+			name: "pausing after a missed first response keeps the breach",
+			steps: func(s *TicketSLA) {
+				s.Pause(at(2 * time.Hour))
+				s.Resume(at(3 * time.Hour))
+			},
+			wantFirstResponse: 2 * time.Hour,
+			wantResolve:       5 * time.Hour,
+			wantBreached:      true,
+		},
+		{
+			name: "late resolve is breached",
+			steps: func(s *TicketSLA) {
+				s.MarkFirstResponse(at(10 * time.Minute))
+				s.MarkResolved(at(5 * time.Hour))
+			},
+			wantFirstResponse: time.Hour,
+			wantResolve:       4 * time.Hour,
+			wantResolved:      true,
+			wantBreached:      true,
+		},
+		{
+			name: "reopen keeps an earlier breach",
+			steps: func(s *TicketSLA) {
+				s.MarkResolved(at(2 * time.Hour))
+				s.Reopen(at(3 * time.Hour))
+			},
+			wantFirstResponse: 2 * time.Hour,
+			wantResolve:       5 * time.Hour,
+			wantBreached:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sla := NewTicketSLA(1, start, time.Hour, 4*time.Hour)
+			tt.steps(sla)
+
+			if want := at(tt.wantFirstResponse); !sla.FirstResponseDueAt.Equal(want) {
+				t.Errorf("first response due at %v, want %v", sla.FirstResponseDueAt, want)
+			}
+			if want := at(tt.wantResolve); !sla.ResolveDueAt.Equal(want) {
+				t.Errorf("resolve due at %v, want %v", sla.ResolveDueAt, want)
+			}
+			if resolved := sla.ResolvedAt != nil; resolved != tt.wantResolved {
+				t.Errorf("resolved = %v, want %v", resolved, tt.wantResolved)
+			}
+			if sla.PausedAt != nil {
+				t.Errorf("clock still paused at %v", *sla.PausedAt)
+			}
+			if sla.Breached != tt.wantBreached {
+				t.Errorf("breached = %v, want %v", sla.Breached, tt.wantBreached)
+			}
+		})
+	}
+}
+
+func TestTicketSLAIsBreached(t *testing.T) {
+	start := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	sla := NewTicketSLA(1, start, time.Hour, 4*time.Hour)
+
+	if sla.IsBreached(start.Add(30 * time.Minute)) {
+		t.Error("breached before any deadline")
+	}
+	if !sla.IsBreached(start.Add(2 * time.Hour)) {
+		t.Error("missed first response not reported")
+	}
+
+	sla.Pause(start.Add(30 * time.Minute))
+	if sla.IsBreached(start.Add(10 * time.Hour)) {
+		t.Error("a paused clock must not breach")
+	}
+
+	sla.Resume(start.Add(90 * time.Minute))
+	sla.MarkFirstResponse(start.Add(100 * time.Minute))
+	if sla.IsBreached(start.Add(290 * time.Minute)) {
+		t.Error("breached before the moved resolve deadline")
+	}
+	if !sla.IsBreached(start.Add(310 * time.Minute)) {
+		t.Error("missed resolve deadline not reported")
+	}
+}
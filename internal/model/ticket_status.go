@@ -0,0 +1,20 @@
+package model
+
+// Ticket status kinds. Workflow logic relies on the kind of a status,
+// never on its title or its position in the statuses list.
+const (
+	TicketStatusKindInitial  = "initial"  // status of newly created tickets
+	TicketStatusKindOpen     = "open"     // an agent is working on the ticket
+	TicketStatusKindPending  = "pending"  // waiting on the requester
+	TicketStatusKindResolved = "resolved" // solved, awaiting closure
+	TicketStatusKindClosed   = "closed"   // final status
+)
+
+// TicketStatusKinds lists every valid ticket status kind
+var TicketStatusKinds = []string{
+	TicketStatusKindInitial,
+	TicketStatusKindOpen,
+	TicketStatusKindPending,
+	TicketStatusKindResolved,
+	TicketStatusKindClosed,
+}
@@ -4,12 +4,17 @@ import (
 	"database/sql"
 	"ticket-api/internal/db/api_keys"
 	"ticket-api/internal/db/api_routes"
+	"ticket-api/internal/db/canned_responses"
 	"ticket-api/internal/db/departments"
 	"ticket-api/internal/db/roles"
 	"ticket-api/internal/db/roles_relations"
+	"ticket-api/internal/db/sla_policies"
+	"ticket-api/internal/db/tags"
 	"ticket-api/internal/db/ticket_priorities"
 	"ticket-api/internal/db/ticket_statuses"
+	"ticket-api/internal/db/ticket_type_fields"
 	"ticket-api/internal/db/ticket_types"
+	"ticket-api/internal/db/ticket_views"
 	"ticket-api/internal/db/users"
 	"ticket-api/internal/db/version"
 	"ticket-api/internal/services"
@@ -20,34 +25,51 @@ import (
 type AppRepositories struct {
 	Ticket           *TicketRepository
 	ChatRepository   *ChatRepository
+	TicketHistory    *TicketHistoryRepository
 	Version          *VersionRepository
 	Roles            *RolesRepository
 	Departments      *DepartmentsRepository
 	TicketTypes      *TicketTypesRepository
+	TicketTypeFields *TicketTypeFieldsRepository
 	TicketPriorities *TicketPrioritiesRepository
 	APIRoutes        *APIRoutesRepository
 	RolesRelations   *RolesRelationsRepository
 	Users            *UsersRepository
 	TicketStatus     *TicketStatusesRepository
 	APIKeys          *APIKeysRepository
+	SLAPolicies      *SLAPoliciesRepository
+	TicketViews      *TicketViewsRepository
+	Tags             *TagsRepository
+	CannedResponses  *CannedResponsesRepository
 }
 
 func NewRepositories(sqldb *sql.DB, mongodb *mongo.Database, services *services.AppServices) *AppRepositories {
+	ticketHistory := NewTicketHistoryRepository(mongodb)
+
 	return &AppRepositories{
-		Ticket:           NewTicketRepository(mongodb, services.FileStorage),
-		ChatRepository:   NewChatRepository(mongodb, services.FileStorage),
+		Ticket:           NewTicketRepository(mongodb, services.FileStorage, ticketHistory),
+		ChatRepository:   NewChatRepository(mongodb, services.FileStorage, ticketHistory),
+		TicketHistory:    ticketHistory,
 		Version:          NewVersionRepository(version.New(sqldb)),
 		Roles:            NewRolesRepository(roles.New(sqldb)),
 		Departments:      NewDepartmentsRepository(departments.New(sqldb), services.Cache),
 		TicketTypes:      NewTicketTypesRepository(ticket_types.New(sqldb), services.Cache),
-		TicketPriorities: NewTicketPrioritiesRepository(ticket_priorities.New(sqldb)),
+		TicketTypeFields: NewTicketTypeFieldsRepository(ticket_type_fields.New(sqldb), services.Cache),
+		TicketPriorities: NewTicketPrioritiesRepository(ticket_priorities.New(sqldb), ticket_types.New(sqldb)),
 		APIRoutes:        NewAPIRoutesRepository(api_routes.New(sqldb)),
 		APIKeys:          NewAPIKeysRepository(api_keys.New(sqldb)),
+		SLAPolicies:      NewSLAPoliciesRepository(sla_policies.New(sqldb)),
+		TicketViews:      NewTicketViewsRepository(ticket_views.New(sqldb)),
+		Tags:             NewTagsRepository(tags.New(sqldb), services.Cache),
+		CannedResponses:  NewCannedResponsesRepository(canned_responses.New(sqldb), services.Cache),
 		RolesRelations: NewRolesRelationRepository(
 			roles_relations.New(sqldb),
 			api_keys.New((sqldb)),
 			api_routes.New(sqldb)),
-		Users:        NewUsersRepository(users.New(sqldb)),
-		TicketStatus: NewTicketStatusesRepository(ticket_statuses.New(sqldb), services.Cache),
+		Users: NewUsersRepository(users.New(sqldb)),
+		TicketStatus: NewTicketStatusesRepository(
+			ticket_statuses.New(sqldb),
+			roles_relations.New(sqldb),
+			services.Cache),
 	}
 }
@@ -0,0 +1,225 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"ticket-api/internal/config"
+	"ticket-api/internal/db/canned_responses"
+	"ticket-api/internal/dto"
+	"ticket-api/internal/errx"
+	"ticket-api/internal/services/cache"
+	"time"
+)
+
+// sqliteTimeLayout is the format of datetime('now')
+const sqliteTimeLayout = "2006-01-02 15:04:05"
+
+type CannedResponsesRepository struct {
+	queries *canned_responses.Queries
+	cache   *cache.CacheService
+}
+
+func NewCannedResponsesRepository(queries *canned_responses.Queries, cache *cache.CacheService) *CannedResponsesRepository {
+	return &CannedResponsesRepository{
+		queries: queries,
+		cache:   cache,
+	}
+}
+
+func (repo *CannedResponsesRepository) AddCannedResponse(ctx context.Context, createdBy int64, req dto.CannedResponseRequest) (int64, *errx.APIError) {
+	if err := req.Validate(); err != nil {
+		return -1, errx.Respond(errx.ErrBadRequest, err)
+	}
+
+	id, err := repo.queries.AddCannedResponse(ctx, canned_responses.AddCannedResponseParams{
+		Title:        req.Title,
+		Body:         req.Body,
+		DepartmentID: nullInt64(req.DepartmentID),
+		TicketTypeID: nullInt64(req.TicketTypeID),
+		CreatedBy:    createdBy,
+	})
+	if err != nil {
+		return -1, errx.Respond(errx.ErrInternalServerError, err)
+	}
+
+	_ = repo.cache.Delete(ctx, CacheKeyCannedResponsesAll)
+	return id, nil
+}
+
+func (repo *CannedResponsesRepository) UpdateCannedResponse(ctx context.Context, req dto.CannedResponseUpdateRequest) *errx.APIError {
+	if err := req.Validate(); err != nil {
+		return errx.Respond(errx.ErrBadRequest, err)
+	}
+
+	rows, err := repo.queries.UpdateCannedResponse(ctx, canned_responses.UpdateCannedResponseParams{
+		Title:        req.Title,
+		Body:         req.Body,
+		DepartmentID: nullInt64(req.DepartmentID),
+		TicketTypeID: nullInt64(req.TicketTypeID),
+		Status:       boolToInt64(req.Active),
+		ID:           req.ID,
+	})
+	if err != nil {
+		return errx.Respond(errx.ErrInternalServerError, err)
+	}
+	if rows == 0 {
+		return errx.Respond(errx.ErrCannedResponseNotFound, fmt.Errorf("canned response %d not found", req.ID))
+	}
+
+	_ = repo.cache.Delete(ctx, CacheKeyCannedResponsesAll)
+	return nil
+}
+
+// DeleteCannedResponse soft deletes a canned response, its usage statistics are kept
+func (repo *CannedResponsesRepository) DeleteCannedResponse(ctx context.Context, id int64) *errx.APIError {
+	rows, err := repo.queries.DeleteCannedResponse(ctx, id)
+	if err != nil {
+		return errx.Respond(errx.ErrInternalServerError, err)
+	}
+	if rows == 0 {
+		return errx.Respond(errx.ErrCannedResponseNotFound, fmt.Errorf("canned response %d not found", id))
+	}
+
+	_ = repo.cache.Delete(ctx, CacheKeyCannedResponsesAll)
+	return nil
+}
+
+// GetAllCannedResponses returns every canned response, including inactive ones, with cache
+func (repo *CannedResponsesRepository) GetAllCannedResponses(ctx context.Context) ([]canned_responses.CannedResponse, *errx.APIError) {
+	var all []canned_responses.CannedResponse
+
+	ok, err := repo.cache.Get(ctx, CacheKeyCannedResponsesAll, &all)
+	if err != nil {
+		return nil, errx.Respond(errx.ErrInternalServerError, err)
+	}
+	if ok {
+		return all, nil
+	}
+
+	all, err = repo.queries.GetAllCannedResponses(ctx)
+	if err != nil {
+		return nil, errx.Respond(errx.ErrInternalServerError, err)
+	}
+
+	_ = repo.cache.Set(ctx, CacheKeyCannedResponsesAll, all, time.Duration(config.Get().Cache.CannedResponseTTL)*time.Minute)
+	return all, nil
+}
+
+// GetCannedResponses returns the canned responses usable on tickets of the department and
+// ticket type of the query: global ones and those scoped to them.
+func (repo *CannedResponsesRepository) GetCannedResponses(ctx context.Context, query dto.CannedResponseQuery) ([]dto.CannedResponseDTO, *errx.APIError) {
+	all, err := repo.GetAllCannedResponses(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]dto.CannedResponseDTO, 0, len(all))
+	for i := range all {
+		if all[i].Status != 1 && !query.IncludeInactive {
+			continue
+		}
+		if query.DepartmentID != 0 && all[i].DepartmentID.Valid && all[i].DepartmentID.Int64 != query.DepartmentID {
+			continue
+		}
+		if query.TicketTypeID != 0 && all[i].TicketTypeID.Valid && all[i].TicketTypeID.Int64 != query.TicketTypeID {
+			continue
+		}
+		responses = append(responses, *dto.ToCannedResponseDTO(&all[i]))
+	}
+	return responses, nil
+}
+
+// GetCannedResponseForTicket returns an active canned response that may be posted on a
+// ticket of the given department and type
+func (repo *CannedResponsesRepository) GetCannedResponseForTicket(ctx context.Context, id, departmentID, ticketTypeID int64) (*canned_responses.CannedResponse, *errx.APIError) {
+	all, err := repo.GetAllCannedResponses(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range all {
+		response := &all[i]
+		if response.ID != id {
+			continue
+		}
+		if response.Status != 1 {
+			break
+		}
+		if response.DepartmentID.Valid && response.DepartmentID.Int64 != departmentID {
+			return nil, errx.Respond(errx.ErrCannedResponseNotInScope, fmt.Errorf("canned response %d belongs to department %d, ticket to %d", id, response.DepartmentID.Int64, departmentID))
+		}
+		if response.TicketTypeID.Valid && response.TicketTypeID.Int64 != ticketTypeID {
+			return nil, errx.Respond(errx.ErrCannedResponseNotInScope, fmt.Errorf("canned response %d belongs to ticket type %d, ticket is %d", id, response.TicketTypeID.Int64, ticketTypeID))
+		}
+		return response, nil
+	}
+	return nil, errx.Respond(errx.ErrCannedResponseNotFound, fmt.Errorf("canned response %d not found or inactive", id))
+}
+
+// RecordCannedResponseUse counts a post of a canned response by an agent on a ticket
+func (repo *CannedResponsesRepository) RecordCannedResponseUse(ctx context.Context, id, userID int64, ticketID string) *errx.APIError {
+	err := repo.queries.AddCannedResponseUse(ctx, canned_responses.AddCannedResponseUseParams{
+		CannedResponseID: id,
+		UserID:           userID,
+		TicketID:         ticketID,
+	})
+	if err != nil {
+		return errx.Respond(errx.ErrInternalServerError, err)
+	}
+	return nil
+}
+
+// GetCannedResponseUsage counts the posts of each canned response in the period of the
+// request, most used first. Deleted responses keep their statistics and an empty title.
+func (repo *CannedResponsesRepository) GetCannedResponseUsage(ctx context.Context, req dto.CannedResponseUsageRequest) ([]dto.CannedResponseUsageDTO, *errx.APIError) {
+	to := time.Now()
+	if req.To != nil {
+		to = *req.To
+	}
+	from := to.AddDate(0, 0, -30)
+	if req.From != nil {
+		from = *req.From
+	}
+	if !from.Before(to) {
+		return nil, errx.Respond(errx.ErrBadRequest, errors.New("from must be before to"))
+	}
+
+	rows, err := repo.queries.GetCannedResponseUsage(ctx, canned_responses.GetCannedResponseUsageParams{
+		UsedFrom: from.UTC().Format(sqliteTimeLayout),
+		UsedTo:   to.UTC().Format(sqliteTimeLayout),
+	})
+	if err != nil {
+		return nil, errx.Respond(errx.ErrInternalServerError, err)
+	}
+
+	all, apiErr := repo.GetAllCannedResponses(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	catalog := make(map[int64]*canned_responses.CannedResponse, len(all))
+	for i := range all {
+		catalog[all[i].ID] = &all[i]
+	}
+
+	usage := make([]dto.CannedResponseUsageDTO, 0, len(rows))
+	for _, row := range rows {
+		item := dto.CannedResponseUsageDTO{
+			CannedResponseID: row.CannedResponseID,
+			Uses:             row.Uses,
+			Agents:           row.Agents,
+			LastUsedAt:       row.LastUsedAt,
+		}
+		if response, ok := catalog[row.CannedResponseID]; ok {
+			item.Title = response.Title
+			if response.DepartmentID.Valid {
+				item.DepartmentID = &response.DepartmentID.Int64
+			}
+		}
+		if req.DepartmentID != nil && item.DepartmentID != nil && *item.DepartmentID != *req.DepartmentID {
+			continue
+		}
+		usage = append(usage, item)
+	}
+	return usage, nil
+}
@@ -3,35 +3,64 @@ package repository
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log"
+	"slices"
 	"ticket-api/internal/config"
 	"ticket-api/internal/dto"
 	"ticket-api/internal/errx"
+	"ticket-api/internal/model"
 	"ticket-api/internal/services/storage"
 	"ticket-api/internal/util"
+	"time"
 
 	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
+// chatOrder sorts messages the way they were sent
+var chatOrder = bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}}
+
 type ChatRepository struct {
-	collection *mongo.Collection
+	collection *mongo.Collection // tickets
+	messages   *mongo.Collection // chat messages of all tickets
 	storage    *storage.StorageService
+	history    *TicketHistoryRepository
 }
 
-// NewChatRepository creates a new ChatRepository
-func NewChatRepository(db *mongo.Database, storage *storage.StorageService) *ChatRepository {
+// NewChatRepository creates a new ChatRepository and the indexes of the chat collection
+func NewChatRepository(db *mongo.Database, storage *storage.StorageService, history *TicketHistoryRepository) *ChatRepository {
 	if !config.Get().Mongo.Enable {
 		return &ChatRepository{}
 	}
+
+	messages := db.Collection(config.Get().Mongo.ChatCollectionName)
+
+	// Messages are always read per ticket, in chat order
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := messages.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "ticketId", Value: 1}, {Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}},
+		Options: options.Index().SetName("chat_ticket_order"),
+	})
+	if err != nil {
+		log.Printf("⚠️ failed to create chat index: %v", err)
+	}
+
 	return &ChatRepository{
 		collection: db.Collection(config.Get().Mongo.TicketCollectionName),
+		messages:   messages,
 		storage:    storage,
+		history:    history,
 	}
 }
 
-// CreateChatMessageForTicket adds a chat message to an existing ticket
-func (r *ChatRepository) CreateChatMessageForTicket(ctx context.Context, ticketID string, message *dto.ChatMessageCreateRequest) (*dto.ChatMessageDTO, *errx.APIError) {
+// CreateChatMessageForTicket adds a chat message to an existing ticket. The attachments must
+// have been uploaded by the uploader. senderIsStaff tells whether the sender was authenticated
+// as staff, only their replies stop the first response clock.
+func (r *ChatRepository) CreateChatMessageForTicket(ctx context.Context, ticketID string, uploader storage.Uploader, senderIsStaff bool, message *dto.ChatMessageCreateRequest) (*dto.ChatMessageDTO, *errx.APIError) {
 
 	// Validate UUID
 	uid, err := uuid.Parse(ticketID)
@@ -39,36 +68,629 @@ func (r *ChatRepository) CreateChatMessageForTicket(ctx context.Context, ticketI
 		return nil, errx.Respond(errx.ErrBadRequest, err)
 	}
 
-	model := message.ToModel()
-	attachments, err := util.ParseObjectNames(model.Attachments)
+	chat := message.ToModel()
+	chat.TicketID = uid.String()
+	attachments, err := util.ParseObjectNames(chat.Attachments)
 	if err != nil {
 		return nil, errx.Respond(errx.ErrBadRequest, err)
 	}
 
-	attachments, apiErr := r.storage.MoveTempsFileToTickets(ctx, uid.String(), attachments)
+	attachments, apiErr := r.storage.MoveTempsFileToTickets(ctx, uid.String(), uploader, attachments)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	chat.Attachments = attachments
+	set := bson.M{"lastMessage": chat.Ref()}
+	update := bson.M{
+		"$set": set,
+		"$inc": bson.M{"attachmentCount": len(attachments)},
+	}
+	if !chat.Internal {
+		set["lastPublicMessage"] = chat.Ref()
+		// internal notes stay out of the search index so searches never reveal them
+		update["$push"] = bson.M{"searchText": util.NormalizeSearchText(chat.Message)}
+	}
+
+	// The message goes in first so the ticket never points at a missing message
+	if _, err := r.messages.InsertOne(ctx, chat); err != nil {
+		return nil, errx.Respond(errx.ErrInternalServerError, err)
+	}
+
+	// Return the ticket owner and SLA as they were before the message
+	opts := options.FindOneAndUpdate().SetProjection(bson.M{"userId": 1, "sla": 1})
+
+	var ticket model.Ticket
+	err = r.collection.FindOneAndUpdate(ctx, bson.M{"_id": uid.String()}, update, opts).Decode(&ticket)
+	if err != nil {
+		// without the ticket update the message is removed again
+		if _, delErr := r.messages.DeleteOne(ctx, bson.M{"_id": chat.ID}); delErr != nil {
+			log.Printf("⚠️ failed to remove message %s of ticket %s that was not updated: %v", chat.ID, uid.String(), delErr)
+		}
+		if err == mongo.ErrNoDocuments {
+			return nil, errx.Respond(errx.ErrTicketNotFound, errors.New("ticket not found"))
+		}
+		return nil, errx.Respond(errx.ErrInternalServerError, err)
+	}
+
+	// The first staff reply to someone else's ticket stops the first response clock.
+	// Internal notes are not replies.
+	if ticket.SLA != nil && ticket.SLA.FirstRespondedAt == nil && senderIsStaff && chat.SenderID != ticket.UserID && !chat.Internal {
+		if _, apiErr := updateTicketSLA(ctx, r.collection, uid.String(), func(sla *model.TicketSLA) {
+			sla.MarkFirstResponse(chat.CreatedAt)
+		}); apiErr != nil {
+			// the message is already stored, failing now would make clients post it again
+			log.Printf("⚠️ failed to record first response of ticket %s: %v", uid.String(), apiErr)
+		}
+	}
+
+	// Whoever writes a message has read the chat up to it
+	if _, err := r.advanceReadReceipt(ctx, uid.String(), model.ReadReceipt{
+		UserID:        chat.SenderID,
+		MessageID:     chat.ID,
+		MessageSentAt: chat.CreatedAt,
+		ReadAt:        chat.CreatedAt,
+		Internal:      chat.Internal,
+	}); err != nil {
+		log.Printf("⚠️ failed to update read receipt of user %d on ticket %s: %v", chat.SenderID, uid.String(), err)
+	}
+
+	r.history.Record(ctx, model.TicketEvent{
+		TicketID:  uid.String(),
+		ActorID:   chat.SenderID,
+		Action:    model.TicketEventChatMessage,
+		NewValue:  chat.ID,
+		CreatedAt: chat.CreatedAt,
+	})
+
+	chatDTO := dto.ToChatMessageDTO(chat)
+	return &chatDTO, nil
+}
+
+// GetTicketMessages returns a page of the chat of a ticket the reader may see. Internal
+// notes are only included for staff.
+func (r *ChatRepository) GetTicketMessages(ctx context.Context, ticketID string, readerID int64, isStaff bool, query dto.ChatMessagesQuery) (*dto.ChatMessagesPage, *errx.APIError) {
+	if query.Before != "" && query.After != "" {
+		return nil, errx.Respond(errx.ErrBadRequest, errors.New("before and after cannot be combined"))
+	}
+
+	ticket, apiErr := r.getReadableTicket(ctx, ticketID, readerID, isStaff)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	cfg := config.Get().TicketConfig
+	limit := query.Limit
+	if limit < cfg.MinPagingSize || limit > cfg.MaxPagingSize {
+		limit = cfg.DefaultPagingSize
+	}
+
+	filter := bson.M{"ticketId": ticket.ID}
+	if !isStaff {
+		filter["internal"] = bson.M{"$ne": true}
+	}
+
+	// newest first unless paging forward
+	cursorID, forward := query.Before, false
+	if query.After != "" {
+		cursorID, forward = query.After, true
+	}
+	msgs, hasMore, apiErr := findChatPage(ctx, r.messages, filter, cursorID, forward, limit)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	items := make([]dto.ChatMessageDTO, len(msgs))
+	for i := range msgs {
+		items[i] = dto.ToChatMessageDTO(&msgs[i])
+	}
+	return &dto.ChatMessagesPage{Items: items, HasMore: hasMore}, nil
+}
+
+// EditChatMessage replaces the text of a message sent by actorID, keeping the previous text as a revision
+func (r *ChatRepository) EditChatMessage(ctx context.Context, ticketID string, messageID string, actorID int64, text string) (*dto.ChatMessageDTO, *errx.APIError) {
+	msg, apiErr := r.getOwnChatMessage(ctx, ticketID, messageID, actorID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	now := time.Now()
+	revision := model.ChatRevision{Message: msg.Message, EditedAt: now}
+	msg.Revisions = append(msg.Revisions, revision)
+	msg.Message = text
+	previousUpdatedAt := msg.UpdatedAt
+	msg.UpdatedAt = now
+
+	update := bson.M{
+		"$set": bson.M{
+			"message":   msg.Message,
+			"updatedAt": now,
+		},
+		"$push": bson.M{"revisions": revision},
+	}
+	if apiErr := r.updateChatMessage(ctx, msg, previousUpdatedAt, update); apiErr != nil {
+		return nil, apiErr
+	}
+	if apiErr := r.refreshTicketChat(ctx, msg.TicketID, now, 0); apiErr != nil {
+		return nil, apiErr
+	}
+
+	r.history.Record(ctx, model.TicketEvent{
+		TicketID:  msg.TicketID,
+		ActorID:   actorID,
+		Action:    model.TicketEventChatEdited,
+		NewValue:  messageID,
+		CreatedAt: now,
+	})
+
+	chatDTO := dto.ToChatMessageDTO(msg)
+	return &chatDTO, nil
+}
+
+// DeleteChatMessage soft deletes a message sent by actorID and removes its attachments from storage.
+// The text and revisions stay in the database for auditing. Returns the message as clients now see it.
+func (r *ChatRepository) DeleteChatMessage(ctx context.Context, ticketID string, messageID string, actorID int64) (*dto.ChatMessageDTO, *errx.APIError) {
+	msg, apiErr := r.getOwnChatMessage(ctx, ticketID, messageID, actorID)
 	if apiErr != nil {
-		return nil, errx.Respond(errx.ErrBadRequest, apiErr)
+		return nil, apiErr
 	}
 
-	model.Attachments = attachments
+	now := time.Now()
+	attachments := msg.Attachments
+	previousUpdatedAt := msg.UpdatedAt
+	msg.DeletedAt = &now
+	msg.UpdatedAt = now
+
 	update := bson.M{
-		"$push": bson.M{"chat": model},
-		"$inc":  bson.M{"attachmentCount": len(attachments)},
+		"$set": bson.M{
+			"deletedAt":   now,
+			"updatedAt":   now,
+			"attachments": []string{},
+		},
+	}
+	if apiErr := r.updateChatMessage(ctx, msg, previousUpdatedAt, update); apiErr != nil {
+		return nil, apiErr
+	}
+	if apiErr := r.refreshTicketChat(ctx, msg.TicketID, now, -len(attachments)); apiErr != nil {
+		return nil, apiErr
+	}
+
+	// the message no longer references the files, so a failed removal only leaves orphans behind
+	for _, name := range attachments {
+		if apiErr := r.storage.DeleteTicketFile(ctx, msg.TicketID, name); apiErr != nil && apiErr.Err.Code != errx.ErrFileNotFound {
+			log.Printf("⚠️ failed to delete attachment %s of ticket %s: %v", name, msg.TicketID, apiErr)
+		}
+	}
+
+	r.history.Record(ctx, model.TicketEvent{
+		TicketID:  msg.TicketID,
+		ActorID:   actorID,
+		Action:    model.TicketEventChatDeleted,
+		NewValue:  messageID,
+		CreatedAt: now,
+	})
+
+	chatDTO := dto.ToChatMessageDTO(msg)
+	return &chatDTO, nil
+}
+
+// MarkTicketRead moves the read receipt of a user forward to a chat message, or to the latest
+// message the user can see when messageID is empty. Receipts never move back, so a stale
+// request leaves a newer receipt in place. Returns the receipt and the message it points at.
+func (r *ChatRepository) MarkTicketRead(ctx context.Context, ticketID string, readerID int64, isStaff bool, messageID string) (*dto.ReadReceiptDTO, *dto.ChatMessageDTO, *errx.APIError) {
+	ticket, apiErr := r.getReadableTicket(ctx, ticketID, readerID, isStaff)
+	if apiErr != nil {
+		return nil, nil, apiErr
+	}
+
+	filter := bson.M{"ticketId": ticket.ID}
+	if !isStaff {
+		filter["internal"] = bson.M{"$ne": true}
+	}
+	opts := options.FindOne()
+	if messageID != "" {
+		filter["_id"] = messageID
+	} else {
+		opts.SetSort(bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}})
+	}
+
+	var msg model.ChatMessage
+	if err := r.messages.FindOne(ctx, filter, opts).Decode(&msg); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil, errx.Respond(errx.ErrChatMessageNotFound, fmt.Errorf("no message to mark read in ticket %s", ticket.ID))
+		}
+		return nil, nil, errx.Respond(errx.ErrInternalServerError, err)
+	}
+	msgDTO := dto.ToChatMessageDTO(&msg)
+
+	receipt := model.ReadReceipt{
+		UserID:        readerID,
+		MessageID:     msg.ID,
+		MessageSentAt: msg.CreatedAt,
+		ReadAt:        time.Now(),
+		Internal:      msg.Internal,
+	}
+	moved, err := r.advanceReadReceipt(ctx, ticket.ID, receipt)
+	if err != nil {
+		return nil, nil, errx.Respond(errx.ErrInternalServerError, err)
+	}
+	if moved {
+		receiptDTO := dto.ToReadReceiptDTO(&receipt)
+		return &receiptDTO, &msgDTO, nil
+	}
+
+	// The user already read this message or a later one
+	for i := range ticket.ReadReceipts {
+		current := &ticket.ReadReceipts[i]
+		if current.UserID == readerID && !current.MessageSentAt.Before(msg.CreatedAt) {
+			var currentMsg model.ChatMessage
+			if err := r.messages.FindOne(ctx, bson.M{"_id": current.MessageID, "ticketId": ticket.ID}).Decode(&currentMsg); err == nil {
+				msgDTO = dto.ToChatMessageDTO(&currentMsg)
+			}
+			receiptDTO := dto.ToReadReceiptDTO(current)
+			return &receiptDTO, &msgDTO, nil
+		}
+	}
+	return nil, nil, errx.Respond(errx.ErrTicketConcurrentUpdate, errors.New("read receipt was changed by another request"))
+}
+
+// advanceReadReceipt stores the receipt unless the user already has one for the same or a
+// later message. Reports whether the receipt was stored.
+func (r *ChatRepository) advanceReadReceipt(ctx context.Context, ticketID string, receipt model.ReadReceipt) (bool, error) {
+	// Move an older receipt forward
+	result, err := r.collection.UpdateOne(ctx, bson.M{
+		"_id":          ticketID,
+		"readReceipts": bson.M{"$elemMatch": bson.M{"userId": receipt.UserID, "messageSentAt": bson.M{"$lt": receipt.MessageSentAt}}},
+	}, bson.M{"$set": bson.M{"readReceipts.$": receipt}})
+	if err != nil {
+		return false, err
+	}
+	if result.MatchedCount > 0 {
+		return true, nil
+	}
+
+	// First receipt of the user on this ticket
+	result, err = r.collection.UpdateOne(ctx, bson.M{
+		"_id":                 ticketID,
+		"readReceipts.userId": bson.M{"$ne": receipt.UserID},
+	}, bson.M{"$push": bson.M{"readReceipts": receipt}})
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// MigrateEmbeddedChats moves the chat messages embedded in ticket documents, as they were
+// stored before messages got their own collection, into the chat collection. Each ticket is
+// migrated on its own and messages already moved are skipped, so it can be rerun after a failure.
+// Returns the number of migrated tickets and messages.
+func (r *ChatRepository) MigrateEmbeddedChats(ctx context.Context) (int, int, error) {
+	opts := options.Find().SetProjection(bson.M{"chat": 1})
+	cursor, err := r.collection.Find(ctx, bson.M{"chat": bson.M{"$exists": true}}, opts)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	tickets, messages := 0, 0
+	for cursor.Next(ctx) {
+		var legacy struct {
+			ID   string              `bson:"_id"`
+			Chat []model.ChatMessage `bson:"chat"`
+		}
+		if err := cursor.Decode(&legacy); err != nil {
+			return tickets, messages, err
+		}
+
+		var last, lastPublic *model.ChatMessageRef
+		if len(legacy.Chat) > 0 {
+			docs := make([]any, len(legacy.Chat))
+			for i := range legacy.Chat {
+				msg := &legacy.Chat[i]
+				msg.TicketID = legacy.ID
+				docs[i] = msg
+				if !msg.IsDeleted() {
+					last = msg.Ref()
+					if !msg.Internal {
+						lastPublic = msg.Ref()
+					}
+				}
+			}
+			_, err := r.messages.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+			if err != nil && !onlyDuplicateKeyErrors(err) {
+				return tickets, messages, fmt.Errorf("ticket %s: %w", legacy.ID, err)
+			}
+		}
+
+		// Messages written since the deploy already set the references, those win
+		update := mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{
+				"lastMessage":       bson.M{"$ifNull": bson.A{"$lastMessage", bson.M{"$literal": last}}},
+				"lastPublicMessage": bson.M{"$ifNull": bson.A{"$lastPublicMessage", bson.M{"$literal": lastPublic}}},
+			}}},
+			{{Key: "$unset", Value: "chat"}},
+		}
+		if _, err := r.collection.UpdateOne(ctx, bson.M{"_id": legacy.ID}, update); err != nil {
+			return tickets, messages, fmt.Errorf("ticket %s: %w", legacy.ID, err)
+		}
+
+		tickets++
+		messages += len(legacy.Chat)
 	}
+	return tickets, messages, cursor.Err()
+}
+
+// BackfillSearchText builds the search text of tickets created before it was stored, from
+// their title and public, non-deleted messages. Run it after MigrateEmbeddedChats so the
+// messages are in the chat collection. Returns the number of updated tickets.
+func (r *ChatRepository) BackfillSearchText(ctx context.Context) (int, error) {
+	opts := options.Find().SetProjection(bson.M{"title": 1})
+	cursor, err := r.collection.Find(ctx, bson.M{"searchText": bson.M{"$exists": false}}, opts)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	tickets := 0
+	for cursor.Next(ctx) {
+		var ticket model.Ticket
+		if err := cursor.Decode(&ticket); err != nil {
+			return tickets, err
+		}
+
+		chats, err := findChats(ctx, r.messages, bson.M{"ticketId": ticket.ID})
+		if err != nil {
+			return tickets, fmt.Errorf("ticket %s: %w", ticket.ID, err)
+		}
+		ticket.Chat = chats[ticket.ID]
+
+		// messages written since the deploy already pushed the full search text
+		filter := bson.M{"_id": ticket.ID, "searchText": bson.M{"$exists": false}}
+		update := bson.M{"$set": bson.M{"searchText": chatSearchText(&ticket)}}
+		if _, err := r.collection.UpdateOne(ctx, filter, update); err != nil {
+			return tickets, fmt.Errorf("ticket %s: %w", ticket.ID, err)
+		}
+		tickets++
+	}
+	return tickets, cursor.Err()
+}
+
+// onlyDuplicateKeyErrors reports whether every failed write of a bulk insert was a duplicate key
+func onlyDuplicateKeyErrors(err error) bool {
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil {
+		return false
+	}
+	for _, writeErr := range bulkErr.WriteErrors {
+		if !mongo.IsDuplicateKeyError(writeErr) {
+			return false
+		}
+	}
+	return true
+}
+
+// GetAttachmentMessage returns the message of a ticket that references an attachment.
+// Files no message of the ticket references, including those of deleted messages, answer
+// ErrFileNotFound.
+func (r *ChatRepository) GetAttachmentMessage(ctx context.Context, ticketID string, filename string) (*model.ChatMessage, *errx.APIError) {
+
+	// Validate UUID
+	uid, err := uuid.Parse(ticketID)
+	if err != nil {
+		return nil, errx.Respond(errx.ErrBadRequest, err)
+	}
+
+	filter := bson.M{
+		"ticketId":    uid.String(),
+		"attachments": filename,
+		"deletedAt":   bson.M{"$exists": false},
+	}
+
+	var msg model.ChatMessage
+	err = r.messages.FindOne(ctx, filter).Decode(&msg)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errx.Respond(errx.ErrFileNotFound, fmt.Errorf("no message of ticket %s references %s", uid.String(), filename))
+		}
+		return nil, errx.Respond(errx.ErrInternalServerError, err)
+	}
+	return &msg, nil
+}
+
+// getReadableTicket loads the owner and read receipts of a ticket the reader may see.
+// Tickets of other users answer ErrTicketNotFound unless the reader is staff.
+func (r *ChatRepository) getReadableTicket(ctx context.Context, ticketID string, readerID int64, isStaff bool) (*model.Ticket, *errx.APIError) {
+
+	// Validate UUID
+	uid, err := uuid.Parse(ticketID)
+	if err != nil {
+		return nil, errx.Respond(errx.ErrBadRequest, err)
+	}
+
+	opts := options.FindOne().SetProjection(bson.M{"userId": 1, "readReceipts": 1})
+
+	var ticket model.Ticket
+	err = r.collection.FindOne(ctx, bson.M{"_id": uid.String()}, opts).Decode(&ticket)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errx.Respond(errx.ErrTicketNotFound, errors.New("ticket not found"))
+		}
+		return nil, errx.Respond(errx.ErrInternalServerError, err)
+	}
+	if !isStaff && ticket.UserID != readerID {
+		return nil, errx.Respond(errx.ErrTicketNotFound, errors.New("user did not create this ticket"))
+	}
+	return &ticket, nil
+}
+
+// getOwnChatMessage loads a message of a ticket that actorID may still edit or delete
+func (r *ChatRepository) getOwnChatMessage(ctx context.Context, ticketID string, messageID string, actorID int64) (*model.ChatMessage, *errx.APIError) {
 
-	res, err := r.collection.UpdateOne(ctx, bson.M{"_id": uid.String()}, update)
+	// Validate UUID
+	uid, err := uuid.Parse(ticketID)
 	if err != nil {
+		return nil, errx.Respond(errx.ErrBadRequest, err)
+	}
+
+	var msg model.ChatMessage
+	err = r.messages.FindOne(ctx, bson.M{"_id": messageID, "ticketId": uid.String()}).Decode(&msg)
+	if err != nil && err != mongo.ErrNoDocuments {
 		return nil, errx.Respond(errx.ErrInternalServerError, err)
 	}
-	if res.MatchedCount == 0 {
-		return nil, errx.Respond(errx.ErrTicketNotFound, errors.New("ticket not found"))
-	}
-	return &dto.ChatMessageDTO{
-		ID:          model.ID,
-		SenderID:    model.SenderID,
-		Message:     model.Message,
-		Attachments: model.Attachments,
-		CreatedAt:   model.CreatedAt,
-		UpdatedAt:   model.UpdatedAt,
-	}, nil
+	if err == mongo.ErrNoDocuments || msg.IsDeleted() {
+		return nil, errx.Respond(errx.ErrChatMessageNotFound, fmt.Errorf("message %s not found in ticket %s", messageID, uid.String()))
+	}
+
+	if msg.SenderID != actorID {
+		return nil, errx.Respond(errx.ErrChatMessageNotOwned, fmt.Errorf("message %s was sent by user %d", messageID, msg.SenderID))
+	}
+
+	window := time.Duration(config.Get().TicketConfig.ChatEditWindowMinutes) * time.Minute
+	if time.Since(msg.CreatedAt) > window {
+		return nil, errx.Respond(errx.ErrChatEditWindowExpired, fmt.Errorf("message %s was sent at %s", messageID, msg.CreatedAt))
+	}
+
+	return &msg, nil
+}
+
+// updateChatMessage applies an update to a chat message. The update only applies if the
+// message was not changed since it was read, so concurrent edits are rejected.
+func (r *ChatRepository) updateChatMessage(ctx context.Context, msg *model.ChatMessage, updatedAt time.Time, update bson.M) *errx.APIError {
+	filter := bson.M{"_id": msg.ID, "ticketId": msg.TicketID, "updatedAt": updatedAt}
+
+	result, err := r.messages.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return errx.Respond(errx.ErrInternalServerError, err)
+	}
+	if result.MatchedCount == 0 {
+		return errx.Respond(errx.ErrTicketConcurrentUpdate, errors.New("chat message was changed by another request"))
+	}
+	return nil
+}
+
+// refreshTicketChat updates what a ticket keeps about its chat after a message was edited or
+// deleted: the search text, the latest message references and the attachment count
+func (r *ChatRepository) refreshTicketChat(ctx context.Context, ticketID string, now time.Time, attachmentDelta int) *errx.APIError {
+	var ticket model.Ticket
+	opts := options.FindOne().SetProjection(bson.M{"title": 1})
+	if err := r.collection.FindOne(ctx, bson.M{"_id": ticketID}, opts).Decode(&ticket); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return errx.Respond(errx.ErrTicketNotFound, errors.New("ticket not found"))
+		}
+		return errx.Respond(errx.ErrInternalServerError, err)
+	}
+
+	chats, err := findChats(ctx, r.messages, bson.M{"ticketId": ticketID})
+	if err != nil {
+		return errx.Respond(errx.ErrInternalServerError, err)
+	}
+	ticket.Chat = chats[ticketID]
+
+	set := bson.M{
+		"searchText":        chatSearchText(&ticket),
+		"updatedAt":         now,
+		"lastMessage":       nil,
+		"lastPublicMessage": nil,
+	}
+	for i := range ticket.Chat {
+		msg := &ticket.Chat[i]
+		if msg.IsDeleted() {
+			continue
+		}
+		set["lastMessage"] = msg.Ref()
+		if !msg.Internal {
+			set["lastPublicMessage"] = msg.Ref()
+		}
+	}
+
+	update := bson.M{"$set": set}
+	if attachmentDelta != 0 {
+		update["$inc"] = bson.M{"attachmentCount": attachmentDelta}
+	}
+	if _, err := r.collection.UpdateOne(ctx, bson.M{"_id": ticketID}, update); err != nil {
+		return errx.Respond(errx.ErrInternalServerError, err)
+	}
+	return nil
+}
+
+// findChats loads the messages matching filter, grouped by ticket in chat order
+func findChats(ctx context.Context, messages *mongo.Collection, filter bson.M) (map[string][]model.ChatMessage, error) {
+	cursor, err := messages.Find(ctx, filter, options.Find().SetSort(chatOrder))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var msgs []model.ChatMessage
+	if err := cursor.All(ctx, &msgs); err != nil {
+		return nil, err
+	}
+
+	byTicket := map[string][]model.ChatMessage{}
+	for _, msg := range msgs {
+		byTicket[msg.TicketID] = append(byTicket[msg.TicketID], msg)
+	}
+	return byTicket, nil
+}
+
+// findChatPage returns at most limit messages matching filter next to the message cursorID,
+// older ones unless forward is set, in the order they were sent. A limit of 0 returns all of
+// them. The filter must select a single ticket.
+func findChatPage(ctx context.Context, messages *mongo.Collection, filter bson.M, cursorID string, forward bool, limit int) ([]model.ChatMessage, bool, *errx.APIError) {
+	dir, op := -1, "$lt"
+	if forward {
+		dir, op = 1, "$gt"
+	}
+	if cursorID != "" {
+		var cursorMsg model.ChatMessage
+		err := messages.FindOne(ctx, bson.M{"_id": cursorID, "ticketId": filter["ticketId"]}).Decode(&cursorMsg)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				return nil, false, errx.Respond(errx.ErrChatMessageNotFound, fmt.Errorf("message %s not found in ticket %v", cursorID, filter["ticketId"]))
+			}
+			return nil, false, errx.Respond(errx.ErrInternalServerError, err)
+		}
+		filter["$or"] = bson.A{
+			bson.M{"createdAt": bson.M{op: cursorMsg.CreatedAt}},
+			bson.M{"createdAt": cursorMsg.CreatedAt, "_id": bson.M{op: cursorMsg.ID}},
+		}
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: dir}, {Key: "_id", Value: dir}})
+	if limit > 0 {
+		// one extra message tells whether there are more
+		opts.SetLimit(int64(limit) + 1)
+	}
+
+	cursor, err := messages.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, false, errx.Respond(errx.ErrInternalServerError, err)
+	}
+	defer cursor.Close(ctx)
+
+	var msgs []model.ChatMessage
+	if err := cursor.All(ctx, &msgs); err != nil {
+		return nil, false, errx.Respond(errx.ErrInternalServerError, err)
+	}
+
+	hasMore := limit > 0 && len(msgs) > limit
+	if hasMore {
+		msgs = msgs[:limit]
+	}
+	if dir < 0 {
+		slices.Reverse(msgs)
+	}
+	return msgs, hasMore, nil
+}
+
+// chatSearchText rebuilds the search text of a ticket from its title and the messages
+// the requester can see
+func chatSearchText(ticket *model.Ticket) []string {
+	searchText := []string{util.NormalizeSearchText(ticket.Title)}
+	for _, msg := range ticket.Chat {
+		if !msg.Internal && !msg.IsDeleted() {
+			searchText = append(searchText, util.NormalizeSearchText(msg.Message))
+		}
+	}
+	return searchText
 }
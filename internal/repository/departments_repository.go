@@ -10,10 +10,12 @@ import (
 )
 
 const (
-	CacheKeyTicketTypesAll    = "ticket_types_all"
-	CacheKeyTicketTypesActive = "ticket_types_active"
-	CacheKeyDepartmentsAll    = "departments_all"
-	CacheKeyTicketStatusAll   = "ticket_status_all"
+	CacheKeyTicketTypesAll     = "ticket_types_all"
+	CacheKeyTicketTypesActive  = "ticket_types_active"
+	CacheKeyDepartmentsAll     = "departments_all"
+	CacheKeyTicketStatusAll    = "ticket_status_all"
+	CacheKeyTagsAll            = "tags_all"
+	CacheKeyCannedResponsesAll = "canned_responses_all"
 )
 
 type DepartmentsRepository struct {
@@ -2,9 +2,12 @@ package repository
 
 import (
 	"context"
+	"slices"
+	"ticket-api/internal/config"
 	"ticket-api/internal/db/api_keys"
 	"ticket-api/internal/db/api_routes"
 	"ticket-api/internal/db/roles_relations"
+	"ticket-api/internal/errx"
 )
 
 type RolesRelationsRepository struct {
@@ -45,6 +48,34 @@ func (repo *RolesRelationsRepository) AddAPIKeysToRolesRelation(ctx context.Cont
 	return err
 }
 
+func (repo *RolesRelationsRepository) AddTicketStatusTransitionsToRolesRelation(ctx context.Context, param roles_relations.AddTicketStatusTransitionsToRolesRelationParams) error {
+	err := repo.roleRelationsQueries.AddTicketStatusTransitionsToRolesRelation(ctx, param)
+	return err
+}
+
+// GetUserRoleIDs returns the IDs of the active roles of a user
+func (repo *RolesRelationsRepository) GetUserRoleIDs(ctx context.Context, userID int64) ([]int64, *errx.APIError) {
+	roleIDs, err := repo.roleRelationsQueries.GetUserRoleIDs(ctx, userID)
+	if err != nil {
+		return nil, errx.Respond(errx.ErrInternalServerError, err)
+	}
+	return roleIDs, nil
+}
+
+// IsStaff reports whether the user has one of the staff roles from config
+func (repo *RolesRelationsRepository) IsStaff(ctx context.Context, userID int64) (bool, *errx.APIError) {
+	roleIDs, err := repo.GetUserRoleIDs(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	for _, roleID := range roleIDs {
+		if slices.Contains(config.Get().TicketConfig.StaffRoleIDs, roleID) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 func (repo *RolesRelationsRepository) HasRouteAccess(ctx context.Context) (bool, error) {
 	// Get ID of apiKey
 	apiKeyID, err := repo.apiKeysQueries.GetActiveAPIKeyID(ctx, "SampleKey")
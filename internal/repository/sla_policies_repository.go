@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"ticket-api/internal/db/sla_policies"
+	"ticket-api/internal/errx"
+)
+
+type SLAPoliciesRepository struct {
+	queries *sla_policies.Queries
+}
+
+func NewSLAPoliciesRepository(queries *sla_policies.Queries) *SLAPoliciesRepository {
+	return &SLAPoliciesRepository{
+		queries: queries,
+	}
+}
+
+func (repo *SLAPoliciesRepository) AddSLAPolicy(ctx context.Context, param sla_policies.AddSLAPolicyParams) (int64, *errx.APIError) {
+	policyID, err := repo.queries.AddSLAPolicy(ctx, param)
+	if err != nil {
+		return -1, errx.Respond(errx.ErrInternalServerError, err)
+	}
+	return policyID, nil
+}
+
+// GetMatchingPolicy returns the most specific active policy for a ticket, or nil if none applies
+func (repo *SLAPoliciesRepository) GetMatchingPolicy(ctx context.Context, ticketTypeID int64, departmentID int64, priority int) (*sla_policies.SlaPolicy, *errx.APIError) {
+	policy, err := repo.queries.GetMatchingSLAPolicy(ctx, sla_policies.GetMatchingSLAPolicyParams{
+		TicketTypeID: sql.NullInt64{Int64: ticketTypeID, Valid: true},
+		DepartmentID: sql.NullInt64{Int64: departmentID, Valid: true},
+		Priority:     sql.NullInt64{Int64: int64(priority), Valid: true},
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, errx.Respond(errx.ErrInternalServerError, err)
+	}
+	return &policy, nil
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"ticket-api/internal/config"
+	"ticket-api/internal/db/tags"
+	"ticket-api/internal/errx"
+	"ticket-api/internal/services/cache"
+	"time"
+)
+
+const defaultTagColor = "#808080"
+
+type TagsRepository struct {
+	queries *tags.Queries
+	cache   *cache.CacheService
+}
+
+func NewTagsRepository(queries *tags.Queries, cache *cache.CacheService) *TagsRepository {
+	return &TagsRepository{
+		queries: queries,
+		cache:   cache,
+	}
+}
+
+func (repo *TagsRepository) AddTag(ctx context.Context, title, color string, departmentID *int64) (int64, *errx.APIError) {
+	if color == "" {
+		color = defaultTagColor
+	}
+
+	tagID, err := repo.queries.AddTag(ctx, tags.AddTagParams{
+		Title:        title,
+		Color:        color,
+		DepartmentID: nullInt64(departmentID),
+	})
+	if err != nil {
+		return -1, errx.Respond(errx.ErrInternalServerError, err)
+	}
+
+	_ = repo.cache.Delete(ctx, CacheKeyTagsAll)
+	return tagID, nil
+}
+
+func (repo *TagsRepository) UpdateTag(ctx context.Context, id int64, title, color string, departmentID *int64, active bool) *errx.APIError {
+	if color == "" {
+		color = defaultTagColor
+	}
+	var status int64
+	if active {
+		status = 1
+	}
+
+	rows, err := repo.queries.UpdateTag(ctx, tags.UpdateTagParams{
+		Title:        title,
+		Color:        color,
+		DepartmentID: nullInt64(departmentID),
+		Status:       status,
+		ID:           id,
+	})
+	if err != nil {
+		return errx.Respond(errx.ErrInternalServerError, err)
+	}
+	if rows == 0 {
+		return errx.Respond(errx.ErrTagNotFound, fmt.Errorf("tag %d not found", id))
+	}
+
+	_ = repo.cache.Delete(ctx, CacheKeyTagsAll)
+	return nil
+}
+
+// GetAllTags returns every tag of the catalog, including inactive ones, with cache
+func (repo *TagsRepository) GetAllTags(ctx context.Context) ([]tags.Tag, *errx.APIError) {
+	var all []tags.Tag
+
+	ok, err := repo.cache.Get(ctx, CacheKeyTagsAll, &all)
+	if err != nil {
+		return nil, errx.Respond(errx.ErrInternalServerError, err)
+	}
+	if ok {
+		return all, nil
+	}
+
+	all, err = repo.queries.GetAllTags(ctx)
+	if err != nil {
+		return nil, errx.Respond(errx.ErrInternalServerError, err)
+	}
+
+	_ = repo.cache.Set(ctx, CacheKeyTagsAll, all, time.Duration(config.Get().Cache.TagTTL)*time.Minute)
+	return all, nil
+}
+
+// GetAllActiveTags returns the active tags usable in a department: global tags and the
+// department's own. A departmentID of 0 returns the active tags of every department.
+func (repo *TagsRepository) GetAllActiveTags(ctx context.Context, departmentID int64) ([]tags.Tag, *errx.APIError) {
+	all, err := repo.GetAllTags(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	active := make([]tags.Tag, 0, len(all))
+	for _, tag := range all {
+		if tag.Status != 1 {
+			continue
+		}
+		if departmentID != 0 && tag.DepartmentID.Valid && tag.DepartmentID.Int64 != departmentID {
+			continue
+		}
+		active = append(active, tag)
+	}
+	return active, nil
+}
+
+// GetTagsMap returns every tag of the catalog keyed by ID
+func (repo *TagsRepository) GetTagsMap(ctx context.Context) (map[int64]tags.Tag, *errx.APIError) {
+	all, err := repo.GetAllTags(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int64]tags.Tag, len(all))
+	for _, tag := range all {
+		byID[tag.ID] = tag
+	}
+	return byID, nil
+}
+
+// ValidateTicketTags checks that every tag exists, is active and may be used on a
+// ticket of the given department.
+func (repo *TagsRepository) ValidateTicketTags(ctx context.Context, tagIDs []int64, departmentID int64) *errx.APIError {
+	byID, err := repo.GetTagsMap(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range tagIDs {
+		tag, ok := byID[id]
+		if !ok || tag.Status != 1 {
+			return errx.Respond(errx.ErrTagNotFound, fmt.Errorf("tag %d not found or inactive", id))
+		}
+		if tag.DepartmentID.Valid && tag.DepartmentID.Int64 != departmentID {
+			return errx.Respond(errx.ErrTagNotInDepartment, fmt.Errorf("tag %d belongs to department %d, ticket to %d", id, tag.DepartmentID.Int64, departmentID))
+		}
+	}
+	return nil
+}
+
+func nullInt64(v *int64) sql.NullInt64 {
+	if v == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: *v, Valid: true}
+}
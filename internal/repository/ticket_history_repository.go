@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"ticket-api/internal/config"
+	"ticket-api/internal/dto"
+	"ticket-api/internal/errx"
+	"ticket-api/internal/model"
+	"ticket-api/internal/util"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// TicketHistoryRepository stores the append-only change history of tickets.
+type TicketHistoryRepository struct {
+	collection *mongo.Collection
+}
+
+// NewTicketHistoryRepository initializes a TicketHistoryRepository and its indexes.
+// Returns an empty repository if mongo is disabled.
+func NewTicketHistoryRepository(db *mongo.Database) *TicketHistoryRepository {
+	if !config.Get().Mongo.Enable {
+		return &TicketHistoryRepository{}
+	}
+
+	collection := db.Collection(config.Get().Mongo.TicketHistoryCollectionName)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "ticketId", Value: 1}, {Key: "createdAt", Value: 1}},
+	})
+	if err != nil {
+		log.Printf("⚠️ failed to create ticket history index: %v", err)
+	}
+
+	return &TicketHistoryRepository{collection: collection}
+}
+
+// Record appends events to the ticket history. Recording is best-effort: the change
+// itself is already stored, so failures are logged instead of failing the request.
+func (r *TicketHistoryRepository) Record(ctx context.Context, events ...model.TicketEvent) {
+	if r.collection == nil || len(events) == 0 {
+		return
+	}
+
+	now := time.Now()
+	docs := make([]any, len(events))
+	for i := range events {
+		events[i].ID = util.GenerateUUID()
+		if events[i].CreatedAt.IsZero() {
+			events[i].CreatedAt = now
+		}
+		docs[i] = events[i]
+	}
+
+	if _, err := r.collection.InsertMany(ctx, docs); err != nil {
+		log.Printf("⚠️ failed to record ticket history for %s: %v", events[0].TicketID, err)
+	}
+}
+
+// RecordFieldChange appends an "updated" event if the value actually changed.
+// oldValue and newValue must be comparable.
+func (r *TicketHistoryRepository) RecordFieldChange(ctx context.Context, ticketID string, actorID int64, field string, oldValue any, newValue any) {
+	if oldValue == newValue {
+		return
+	}
+	r.Record(ctx, model.TicketEvent{
+		TicketID: ticketID,
+		ActorID:  actorID,
+		Action:   model.TicketEventUpdated,
+		Field:    field,
+		OldValue: oldValue,
+		NewValue: newValue,
+	})
+}
+
+// GetTicketHistory returns every event of a ticket, oldest first. Without includeInternal,
+// events about internal notes are left out: the chat message of every event is looked up and
+// events pointing at an internal note are dropped.
+func (r *TicketHistoryRepository) GetTicketHistory(ctx context.Context, ticketID string, includeInternal bool) ([]dto.TicketEventDTO, *errx.APIError) {
+	uid, err := uuid.Parse(ticketID)
+	if err != nil {
+		return nil, errx.Respond(errx.ErrBadRequest, err)
+	}
+
+	sort := bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}}
+
+	var cursor *mongo.Cursor
+	if includeInternal {
+		cursor, err = r.collection.Find(ctx, bson.M{"ticketId": uid.String()}, options.Find().SetSort(sort))
+	} else {
+		cursor, err = r.collection.Aggregate(ctx, mongo.Pipeline{
+			{{Key: "$match", Value: bson.M{"ticketId": uid.String()}}},
+			{{Key: "$lookup", Value: bson.M{
+				"from":         config.Get().Mongo.ChatCollectionName,
+				"localField":   "newValue",
+				"foreignField": "_id",
+				"as":           "message",
+			}}},
+			{{Key: "$match", Value: bson.M{"message.internal": bson.M{"$ne": true}}}},
+			{{Key: "$project", Value: bson.M{"message": 0}}},
+			{{Key: "$sort", Value: sort}},
+		})
+	}
+	if err != nil {
+		return nil, errx.Respond(errx.ErrInternalServerError, err)
+	}
+	defer cursor.Close(ctx)
+
+	var events []model.TicketEvent
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, errx.Respond(errx.ErrInternalServerError, err)
+	}
+
+	eventsDTO := make([]dto.TicketEventDTO, len(events))
+	for i := range events {
+		eventsDTO[i] = *dto.ToTicketEventDTO(&events[i])
+	}
+
+	return eventsDTO, nil
+}
@@ -2,17 +2,23 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"ticket-api/internal/config"
 	"ticket-api/internal/db/ticket_priorities"
+	"ticket-api/internal/db/ticket_types"
 	"ticket-api/internal/errx"
 )
 
 type TicketPrioritiesRepository struct {
-	queries *ticket_priorities.Queries
+	queries      *ticket_priorities.Queries
+	typesQueries *ticket_types.Queries
 }
 
-func NewTicketPrioritiesRepository(queries *ticket_priorities.Queries) *TicketPrioritiesRepository {
+func NewTicketPrioritiesRepository(queries *ticket_priorities.Queries, typesQueries *ticket_types.Queries) *TicketPrioritiesRepository {
 	return &TicketPrioritiesRepository{
-		queries: queries,
+		queries:      queries,
+		typesQueries: typesQueries,
 	}
 }
 
@@ -21,7 +27,27 @@ func (repo *TicketPrioritiesRepository) AddTicketPriority(ctx context.Context, t
 	return err
 }
 
+// GetTicketPriority resolves the priority of a new ticket: the rule for the user and ticket type
+// wins, then the default of the ticket type, then the global default from config.
 func (repo *TicketPrioritiesRepository) GetTicketPriority(ctx context.Context, userID int, ticketTypeID int) (int, *errx.APIError) {
-	// TODO: add logic here someone :)
-	return -1, nil
+	rule, err := repo.queries.GetTicketPriorityByID(ctx, ticket_priorities.GetTicketPriorityByIDParams{
+		UserID:       int64(userID),
+		TicketTypeID: int64(ticketTypeID),
+	})
+	if err == nil {
+		return int(rule.Priority), nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return -1, errx.Respond(errx.ErrInternalServerError, err)
+	}
+
+	typeDefault, err := repo.typesQueries.GetTicketTypeDefaultPriority(ctx, int64(ticketTypeID))
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return -1, errx.Respond(errx.ErrInternalServerError, err)
+	}
+	if typeDefault.Valid {
+		return int(typeDefault.Int64), nil
+	}
+
+	return config.Get().TicketConfig.DefaultPriority, nil
 }
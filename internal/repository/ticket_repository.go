@@ -2,6 +2,11 @@ package repository
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
+	"log"
+	"maps"
+	"slices"
 	"strings"
 	"ticket-api/internal/config"
 	"ticket-api/internal/dto"
@@ -9,9 +14,10 @@ import (
 	"ticket-api/internal/model"
 	"ticket-api/internal/services/storage"
 	"ticket-api/internal/util"
+	"time"
 
 	"github.com/google/uuid"
-	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
@@ -19,23 +25,43 @@ import (
 // TicketRepository handles ticket-related MongoDB operations.
 type TicketRepository struct {
 	collection *mongo.Collection
+	messages   *mongo.Collection // chat messages, indexed by ChatRepository
 	storage    *storage.StorageService
+	history    *TicketHistoryRepository
 }
 
-// NewTicketRepository initializes a TicketRepository with the "tickets" collection.
+// NewTicketRepository initializes a TicketRepository with the "tickets" collection and its indexes.
 // Returns an empty repository if ENABLE_MONGO is 0.
-func NewTicketRepository(db *mongo.Database, storage *storage.StorageService) *TicketRepository {
+func NewTicketRepository(db *mongo.Database, storage *storage.StorageService, history *TicketHistoryRepository) *TicketRepository {
 	if !config.Get().Mongo.Enable {
 		return &TicketRepository{}
 	}
+
+	collection := db.Collection(config.Get().Mongo.TicketCollectionName)
+
+	// Text index for GetTickets search. Stemming is disabled ("none") because
+	// MongoDB has no Persian support; the text is normalized by util.NormalizeSearchText instead.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "searchText", Value: "text"}},
+		Options: options.Index().SetName("ticket_search_text").SetDefaultLanguage("none"),
+	})
+	if err != nil {
+		log.Printf("⚠️ failed to create ticket search index: %v", err)
+	}
+
 	return &TicketRepository{
-		collection: db.Collection(config.Get().Mongo.TicketCollectionName),
+		collection: collection,
+		messages:   db.Collection(config.Get().Mongo.ChatCollectionName),
 		storage:    storage,
+		history:    history,
 	}
 }
 
-// CreateTicket inserts a new ticket into MongoDB and returns the ticket ID.
-func (r *TicketRepository) CreateTicket(ctx context.Context, ticketDTO *dto.TicketCreateRequest) (*dto.TicketCreateResponse, *errx.APIError) {
+// CreateTicket inserts a new ticket into MongoDB and returns the ticket ID. The attachments
+// must have been uploaded by the uploader.
+func (r *TicketRepository) CreateTicket(ctx context.Context, uploader storage.Uploader, ticketDTO *dto.TicketCreateRequest) (*dto.TicketCreateResponse, *errx.APIError) {
 	// Parse attachment object names
 	attachments, err := util.ParseObjectNames(ticketDTO.Attachments)
 	if err != nil {
@@ -51,7 +77,7 @@ func (r *TicketRepository) CreateTicket(ctx context.Context, ticketDTO *dto.Tick
 
 	// Move temp attachments to ticket folder if first chat has attachments
 	if len(ticket.Chat) > 0 && len(ticket.Chat[0].Attachments) > 0 {
-		movedAttachments, apiErr := r.storage.MoveTempsFileToTickets(ctx, ticket.ID, attachments)
+		movedAttachments, apiErr := r.storage.MoveTempsFileToTickets(ctx, ticket.ID, uploader, attachments)
 		if apiErr != nil {
 			return nil, apiErr
 		}
@@ -64,14 +90,30 @@ func (r *TicketRepository) CreateTicket(ctx context.Context, ticketDTO *dto.Tick
 		return nil, errx.Respond(errx.ErrInternalServerError, err)
 	}
 
+	// The first message goes to the chat collection; without it the ticket is removed again
+	if _, err := r.messages.InsertOne(ctx, ticket.Chat[0]); err != nil {
+		if _, delErr := r.collection.DeleteOne(ctx, bson.M{"_id": ticket.ID}); delErr != nil {
+			log.Printf("⚠️ failed to remove ticket %s without its first message: %v", ticket.ID, delErr)
+		}
+		return nil, errx.Respond(errx.ErrInternalServerError, err)
+	}
+
+	r.history.Record(ctx, model.TicketEvent{
+		TicketID:  ticket.ID,
+		ActorID:   ticket.UserID,
+		Action:    model.TicketEventCreated,
+		NewValue:  ticket.TrackCode,
+		CreatedAt: ticket.CreatedAt,
+	})
+
 	return &dto.TicketCreateResponse{
 		ID:        ticket.ID,
 		TrackCode: ticket.TrackCode,
 	}, nil
 }
 
-// GetTicketByID retrieves a single ticket by ID and converts it to TicketRaw.
-func (r *TicketRepository) GetTicketByID(ctx context.Context, id string) (*dto.TicketResponse, *errx.APIError) {
+// GetTicketByID retrieves a single ticket by ID with the part of its chat selected by chat.
+func (r *TicketRepository) GetTicketByID(ctx context.Context, id string, chat dto.TicketChatOptions) (*dto.TicketResponse, *errx.APIError) {
 
 	// Validate UUID
 	uid, err := uuid.Parse(id)
@@ -86,8 +128,7 @@ func (r *TicketRepository) GetTicketByID(ctx context.Context, id string) (*dto.T
 		}
 		return nil, errx.Respond(errx.ErrInternalServerError, err)
 	}
-
-	return dto.ToTicketResponse(&ticket), nil
+	return r.ticketWithChat(ctx, &ticket, chat)
 }
 
 func (r *TicketRepository) GetTicketAttachmentCount(ctx context.Context, id string) (int, *errx.APIError) {
@@ -113,7 +154,8 @@ func (r *TicketRepository) GetTicketAttachmentCount(ctx context.Context, id stri
 	return result.AttachmentCount, nil
 }
 
-func (r *TicketRepository) GetTicketByTrackCode(ctx context.Context, trackCode string) (*dto.TicketResponse, *errx.APIError) {
+// GetTicketByTrackCode returns a ticket with the part of its chat selected by chat
+func (r *TicketRepository) GetTicketByTrackCode(ctx context.Context, trackCode string, chat dto.TicketChatOptions) (*dto.TicketResponse, *errx.APIError) {
 
 	code, err := util.ParsTrackCode(trackCode)
 	if err != nil {
@@ -127,7 +169,7 @@ func (r *TicketRepository) GetTicketByTrackCode(ctx context.Context, trackCode s
 		}
 		return nil, errx.Respond(errx.ErrInternalServerError, err)
 	}
-	return dto.ToTicketResponse(&ticket), nil
+	return r.ticketWithChat(ctx, &ticket, chat)
 }
 
 // GetAllTickets retrieves all tickets for a specific user and converts them to TicketRaw.
@@ -142,13 +184,21 @@ func (r *TicketRepository) GetAllTickets(ctx context.Context, userID int) ([]dto
 	}
 	defer cursor.Close(ctx)
 
-	var tickets []dto.TicketResponse
+	var models []*model.Ticket
 	for cursor.Next(ctx) {
 		var t model.Ticket
 		if err := cursor.Decode(&t); err != nil {
 			return nil, errx.Respond(errx.ErrInternalServerError, err)
 		}
-		tickets = append(tickets, *dto.ToTicketResponse(&t))
+		models = append(models, &t)
+	}
+	if err := r.loadChats(ctx, models, false); err != nil {
+		return nil, errx.Respond(errx.ErrInternalServerError, err)
+	}
+
+	var tickets []dto.TicketResponse
+	for _, t := range models {
+		tickets = append(tickets, *dto.ToTicketResponse(t))
 	}
 
 	return tickets, nil
@@ -160,6 +210,10 @@ func (r *TicketRepository) GetTickets(
 ) (*dto.PagingResponse[dto.TicketResponse], *errx.APIError) {
 	cfg := config.Get().TicketConfig
 
+	if err := query.ValidateRun(); err != nil {
+		return nil, errx.Respond(errx.ErrInvalidTicketFilter, err)
+	}
+
 	// Ensure pageSize is within allowed range
 	if query.PageSize < cfg.MinPagingSize || query.PageSize > cfg.MaxPagingSize {
 		query.PageSize = cfg.DefaultPagingSize
@@ -168,32 +222,11 @@ func (r *TicketRepository) GetTickets(
 		query.Page = 1
 	}
 
-	// Build filter
-	filter := bson.M{}
-	if query.StatusID != 0 {
-		filter["ticketStatusId"] = query.StatusID
-	}
-	if query.UserID != 0 {
-		filter["userId"] = query.UserID
-	}
-	if query.DepartmentID != 0 {
-		filter["departmentId"] = query.DepartmentID
-	}
-	if query.TicketTypeID != 0 {
-		filter["ticketTypeId"] = query.TicketTypeID
-	}
+	filter := ticketFilter(query)
 
 	// Sorting
-	allowedSortFields := map[string]bool{
-		"createdAt":      true,
-		"updatedAt":      true,
-		"ticketTypeId":   true,
-		"ticketStatusId": true,
-		"departmentId":   true,
-	}
-
 	sortField := "createdAt"
-	if query.OrderBy != "" && allowedSortFields[query.OrderBy] {
+	if query.OrderBy != "" && ticketSortFields[query.OrderBy] != nil {
 		sortField = query.OrderBy
 	}
 
@@ -202,13 +235,41 @@ func (r *TicketRepository) GetTickets(
 		orderDir = 1
 	}
 
-	skip := (query.Page - 1) * query.PageSize
-	// Use bson.D for Sort, bson.M for everything else
+	// Use bson.D for Sort, bson.M for everything else.
+	// _id breaks ties so the order is stable between pages.
+	sort := bson.D{{Key: sortField, Value: orderDir}, {Key: "_id", Value: orderDir}}
+	projection := bson.M{"searchText": 0} // exclude search fields
+	byRelevance := false
+	if query.Query != "" {
+		if query.OrderBy == "" || query.OrderBy == "relevance" {
+			byRelevance = true
+			sort = bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}, {Key: "_id", Value: 1}}
+		}
+	}
+
 	findOptions := options.Find().
-		SetSkip(int64(skip)).
-		SetLimit(int64(query.PageSize)).
-		SetSort(bson.M{sortField: orderDir}).
-		SetProjection(bson.M{"chat": 0}) // exclude chat field
+		SetSort(sort).
+		SetProjection(projection)
+
+	cursorMode := query.Cursor != nil
+	if cursorMode {
+		if byRelevance {
+			return nil, errx.Respond(errx.ErrInvalidTicketFilter, errors.New("cursor paging cannot be combined with relevance sort"))
+		}
+		if *query.Cursor != "" {
+			after, err := decodeTicketCursor(*query.Cursor, sortField, orderDir)
+			if err != nil {
+				return nil, errx.Respond(errx.ErrInvalidTicketFilter, err)
+			}
+			and, _ := filter["$and"].(bson.A)
+			filter["$and"] = append(and, after)
+		}
+		// one extra item tells whether there is a next page
+		findOptions.SetLimit(int64(query.PageSize) + 1)
+	} else {
+		skip := (query.Page - 1) * query.PageSize
+		findOptions.SetSkip(int64(skip)).SetLimit(int64(query.PageSize))
+	}
 
 	// Fetch tickets
 	cursor, err := r.collection.Find(ctx, filter, findOptions)
@@ -222,17 +283,63 @@ func (r *TicketRepository) GetTickets(
 		return nil, errx.Respond(errx.ErrInternalServerError, err)
 	}
 
-	// Total count with cap
-	max := cfg.MaxCountingItem
-	total, err := r.collection.CountDocuments(ctx, filter, options.Count().SetLimit(max))
-	if err != nil {
-		return nil, errx.Respond(errx.ErrInternalServerError, err)
+	var nextCursor string
+	if cursorMode && len(tickets) > query.PageSize {
+		tickets = tickets[:query.PageSize]
+		last := &tickets[len(tickets)-1]
+		nextCursor, err = encodeTicketCursor(sortField, orderDir, ticketSortFields[sortField](last), last.ID)
+		if err != nil {
+			return nil, errx.Respond(errx.ErrInternalServerError, err)
+		}
+	}
+
+	// The chat is only needed to build the snippets of a search
+	terms := util.SearchTerms(query.Query)
+	if len(terms) > 0 {
+		page := make([]*model.Ticket, len(tickets))
+		for i := range tickets {
+			page[i] = &tickets[i]
+		}
+		if err := r.loadChats(ctx, page, true); err != nil {
+			return nil, errx.Respond(errx.ErrInternalServerError, err)
+		}
 	}
 
 	// Map to DTO
 	ticketsDto := make([]dto.TicketResponse, len(tickets))
 	for i, ticket := range tickets {
+		snippets := ticketSnippets(&ticket, terms)
+		ticket.Chat = nil
 		ticketsDto[i] = *dto.ToTicketResponse(&ticket)
+		ticketsDto[i].Snippets = snippets
+	}
+
+	// Unread counts of the current user
+	if query.ReaderID != 0 && len(tickets) > 0 {
+		counts, err := r.unreadCounts(ctx, tickets, query.ReaderID, query.ReaderIsStaff)
+		if err != nil {
+			return nil, errx.Respond(errx.ErrInternalServerError, err)
+		}
+		for i := range ticketsDto {
+			count := counts[ticketsDto[i].ID]
+			ticketsDto[i].UnreadCount = &count
+		}
+	}
+
+	// Cursor mode skips counting, that is what makes it cheap on deep pages
+	if cursorMode {
+		return &dto.PagingResponse[dto.TicketResponse]{
+			PageSize:   query.PageSize,
+			NextCursor: nextCursor,
+			Items:      ticketsDto,
+		}, nil
+	}
+
+	// Total count with cap
+	max := cfg.MaxCountingItem
+	total, err := r.collection.CountDocuments(ctx, filter, options.Count().SetLimit(max))
+	if err != nil {
+		return nil, errx.Respond(errx.ErrInternalServerError, err)
 	}
 
 	// Calculate total pages
@@ -250,11 +357,405 @@ func (r *TicketRepository) GetTickets(
 	}, nil
 }
 
-func (r *TicketRepository) SetTicketStatus(ctx context.Context, id string, statusId int64) (*dto.TicketResponse, *errx.APIError) {
+// ticketSortFields are the fields GetTickets can order by, with their value on the model
+// so a cursor can be built from the last ticket of a page.
+var ticketSortFields = map[string]func(*model.Ticket) any{
+	"createdAt":      func(t *model.Ticket) any { return t.CreatedAt },
+	"updatedAt":      func(t *model.Ticket) any { return t.UpdatedAt },
+	"ticketTypeId":   func(t *model.Ticket) any { return t.TicketTypeID },
+	"ticketStatusId": func(t *model.Ticket) any { return t.TicketStatusID },
+	"departmentId":   func(t *model.Ticket) any { return t.DepartmentID },
+	"priority":       func(t *model.Ticket) any { return t.Priority },
+}
+
+// BackfillSortFields sets the sort fields missing on tickets created before they existed to
+// their zero value. Cursors cannot point at a missing field, so paging by such a field
+// would skip those tickets. Returns the number of updated tickets.
+func (r *TicketRepository) BackfillSortFields(ctx context.Context) (int64, error) {
+	result, err := r.collection.UpdateMany(ctx,
+		bson.M{"priority": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"priority": int64(0)}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// ticketCursor is the decoded form of the opaque cursor returned as next_cursor.
+// It holds the sort order it was made for and the position of the last ticket of the page.
+type ticketCursor struct {
+	Field string        `bson:"f"`
+	Dir   int           `bson:"d"`
+	Value bson.RawValue `bson:"v"`
+	ID    string        `bson:"id"`
+}
+
+func encodeTicketCursor(field string, dir int, value any, id string) (string, error) {
+	raw, err := bson.Marshal(bson.D{
+		{Key: "f", Value: field},
+		{Key: "d", Value: dir},
+		{Key: "v", Value: value},
+		{Key: "id", Value: id},
+	})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// decodeTicketCursor parses a cursor and returns the filter selecting the tickets after it.
+// The cursor must have been made for the same sort field and direction.
+func decodeTicketCursor(encoded string, field string, dir int) (bson.M, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.New("malformed cursor")
+	}
+
+	var c ticketCursor
+	if err := bson.Unmarshal(raw, &c); err != nil {
+		return nil, errors.New("malformed cursor")
+	}
+	if c.Field != field || c.Dir != dir {
+		return nil, errors.New("cursor does not match the requested sort order")
+	}
+
+	op := "$gt"
+	if dir < 0 {
+		op = "$lt"
+	}
+	return bson.M{"$or": bson.A{
+		bson.M{field: bson.M{op: c.Value}},
+		bson.M{field: c.Value, "_id": bson.M{op: c.ID}},
+	}}, nil
+}
+
+// ticketFilter builds the Mongo filter of a ticket list query
+func ticketFilter(query dto.TicketQueryParams) bson.M {
+	filter := bson.M{}
+	setIDFilter(filter, "ticketStatusId", query.StatusID, query.StatusIDs, query.ExcludeStatusIDs)
+	setIDFilter(filter, "userId", query.UserID, query.UserIDs, query.ExcludeUserIDs)
+	setIDFilter(filter, "departmentId", query.DepartmentID, query.DepartmentIDs, query.ExcludeDepartmentIDs)
+	setIDFilter(filter, "ticketTypeId", query.TicketTypeID, query.TicketTypeIDs, query.ExcludeTicketTypeIDs)
+	setRangeFilter(filter, "createdAt", query.CreatedFrom, query.CreatedTo)
+	setRangeFilter(filter, "updatedAt", query.UpdatedFrom, query.UpdatedTo)
+	if query.HasAttachments != nil {
+		if *query.HasAttachments {
+			filter["attachmentCount"] = bson.M{"$gt": 0}
+		} else {
+			filter["attachmentCount"] = bson.M{"$not": bson.M{"$gt": 0}}
+		}
+	}
+	if len(query.Tags) > 0 || len(query.AnyTags) > 0 {
+		tags := bson.M{}
+		if len(query.Tags) > 0 {
+			tags["$all"] = query.Tags
+		}
+		if len(query.AnyTags) > 0 {
+			tags["$in"] = query.AnyTags
+		}
+		filter["tags"] = tags
+	}
+	for name, f := range query.CustomFields {
+		cond := bson.M{}
+		if f.Eq != nil {
+			cond["$eq"] = f.Eq
+		}
+		if len(f.In) > 0 {
+			cond["$in"] = f.In
+		}
+		if f.From != nil {
+			cond["$gte"] = f.From
+		}
+		if f.To != nil {
+			cond["$lte"] = f.To
+		}
+		filter["customFields."+name] = cond
+	}
+	if query.AssigneeID != 0 {
+		filter["assigneeId"] = query.AssigneeID
+	} else if query.Unassigned {
+		// tickets created before assignment existed have no assigneeId field
+		filter["assigneeId"] = bson.M{"$in": bson.A{0, nil}}
+	}
+
+	// SLA filters, evaluated at query time so untouched tickets are included
+	var and bson.A
+	if query.SLABreached {
+		now := time.Now()
+		and = append(and, bson.M{"$or": bson.A{
+			bson.M{"sla.breached": true},
+			bson.M{"sla.pausedAt": nil, "sla.firstRespondedAt": nil, "sla.firstResponseDueAt": bson.M{"$lt": now}},
+			bson.M{"sla.pausedAt": nil, "sla.resolvedAt": nil, "sla.resolveDueAt": bson.M{"$lt": now}},
+		}})
+	}
+	if query.DueBefore != nil {
+		and = append(and, bson.M{"$or": bson.A{
+			bson.M{"sla.firstRespondedAt": nil, "sla.firstResponseDueAt": bson.M{"$lt": *query.DueBefore}},
+			bson.M{"sla.resolvedAt": nil, "sla.resolveDueAt": bson.M{"$lt": *query.DueBefore}},
+		}})
+	}
+	if len(and) > 0 {
+		filter["$and"] = and
+	}
+
+	// Full-text search
+	if query.Query != "" {
+		filter["$text"] = bson.M{"$search": util.NormalizeSearchText(query.Query)}
+	}
+
+	if query.UnreadByMe && query.ReaderID != 0 {
+		filter["$expr"] = unreadFilterExpr(query.ReaderID, query.ReaderIsStaff)
+	}
+
+	return filter
+}
+
+// unreadFilterExpr is an aggregation expression matching tickets whose latest message the
+// reader can see was sent by someone else after the read receipt of the reader. Writing a
+// message moves the receipt of its sender, so a ticket the reader answered last is read.
+func unreadFilterExpr(readerID int64, isStaff bool) bson.M {
+	last := "$lastPublicMessage"
+	if isStaff {
+		last = "$lastMessage"
+	}
+
+	receipt := bson.M{"$arrayElemAt": bson.A{
+		bson.M{"$filter": bson.M{
+			"input": bson.M{"$ifNull": bson.A{"$readReceipts", bson.A{}}},
+			"as":    "r",
+			"cond":  bson.M{"$eq": bson.A{"$$r.userId", readerID}},
+		}},
+		0,
+	}}
+	// without a receipt every message is unread
+	readUntil := bson.M{"$ifNull": bson.A{bson.M{"$let": bson.M{
+		"vars": bson.M{"receipt": receipt},
+		"in":   "$$receipt.messageSentAt",
+	}}, time.Time{}}}
+
+	// tickets without messages have no lastMessage and compare as null, which is never greater
+	return bson.M{"$and": bson.A{
+		bson.M{"$ne": bson.A{last + ".senderId", readerID}},
+		bson.M{"$gt": bson.A{last + ".createdAt", readUntil}},
+	}}
+}
+
+// unreadCounts returns the number of messages of each ticket the reader has not read:
+// messages of other senders, sent after the read receipt of the reader and not deleted.
+// Internal notes only count for staff.
+func (r *TicketRepository) unreadCounts(ctx context.Context, tickets []model.Ticket, readerID int64, includeInternal bool) (map[string]int64, error) {
+	perTicket := make(bson.A, len(tickets))
+	for i := range tickets {
+		cond := bson.M{"ticketId": tickets[i].ID}
+		for _, receipt := range tickets[i].ReadReceipts {
+			if receipt.UserID == readerID {
+				cond["createdAt"] = bson.M{"$gt": receipt.MessageSentAt}
+			}
+		}
+		perTicket[i] = cond
+	}
+
+	match := bson.M{
+		"$or":       perTicket,
+		"senderId":  bson.M{"$ne": readerID},
+		"deletedAt": nil,
+	}
+	if !includeInternal {
+		match["internal"] = bson.M{"$ne": true}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{"_id": "$ticketId", "unread": bson.M{"$sum": 1}}}},
+	}
+
+	cursor, err := r.messages.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		ID     string `bson:"_id"`
+		Unread int64  `bson:"unread"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.ID] = row.Unread
+	}
+	return counts, nil
+}
+
+// loadChats fills the chat of the tickets from the chat collection. publicOnly leaves out
+// internal notes and deleted messages.
+func (r *TicketRepository) loadChats(ctx context.Context, tickets []*model.Ticket, publicOnly bool) error {
+	if len(tickets) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(tickets))
+	for i, t := range tickets {
+		ids[i] = t.ID
+	}
+	filter := bson.M{"ticketId": bson.M{"$in": ids}}
+	if publicOnly {
+		filter["internal"] = bson.M{"$ne": true}
+		filter["deletedAt"] = nil
+	}
+
+	chats, err := findChats(ctx, r.messages, filter)
+	if err != nil {
+		return err
+	}
+	for _, t := range tickets {
+		t.Chat = chats[t.ID]
+	}
+	return nil
+}
+
+// ticketWithChat loads the chat window selected by chat into ticket and converts it
+func (r *TicketRepository) ticketWithChat(ctx context.Context, ticket *model.Ticket, chat dto.TicketChatOptions) (*dto.TicketResponse, *errx.APIError) {
+	if chat.SkipChat {
+		return dto.ToTicketResponse(ticket), nil
+	}
+
+	filter := bson.M{"ticketId": ticket.ID}
+	if chat.PublicOnly {
+		filter["internal"] = bson.M{"$ne": true}
+	}
+	msgs, hasMore, apiErr := findChatPage(ctx, r.messages, filter, chat.BeforeMessageID, false, chat.MessagesLimit)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	ticket.Chat = msgs
+
+	resp := dto.ToTicketResponse(ticket)
+	resp.HasOlderChat = hasMore
+	return resp, nil
+}
+
+// FindTicketIDs returns the IDs of at most limit tickets matching query, oldest first
+func (r *TicketRepository) FindTicketIDs(ctx context.Context, query dto.TicketQueryParams, limit int) ([]string, *errx.APIError) {
+	if err := query.ValidateRun(); err != nil {
+		return nil, errx.Respond(errx.ErrInvalidTicketFilter, err)
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(int64(limit)).
+		SetProjection(bson.M{"_id": 1})
+
+	cursor, err := r.collection.Find(ctx, ticketFilter(query), opts)
+	if err != nil {
+		return nil, errx.Respond(errx.ErrInternalServerError, err)
+	}
+	defer cursor.Close(ctx)
+
+	var tickets []struct {
+		ID string `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &tickets); err != nil {
+		return nil, errx.Respond(errx.ErrInternalServerError, err)
+	}
+
+	ids := make([]string, len(tickets))
+	for i, t := range tickets {
+		ids[i] = t.ID
+	}
+	return ids, nil
+}
+
+// setIDFilter adds a filter on an ID field matching id or any of ids, and none of excluded.
+func setIDFilter(filter bson.M, field string, id int64, ids []int64, excluded []int64) {
+	if id != 0 {
+		ids = append(slices.Clone(ids), id)
+	}
+
+	cond := bson.M{}
+	if len(ids) > 0 {
+		cond["$in"] = ids
+	}
+	if len(excluded) > 0 {
+		cond["$nin"] = excluded
+	}
+	if len(cond) > 0 {
+		filter[field] = cond
+	}
+}
+
+// setRangeFilter adds an inclusive range filter on a date field. Nil bounds are open.
+func setRangeFilter(filter bson.M, field string, from, to *time.Time) {
+	cond := bson.M{}
+	if from != nil {
+		cond["$gte"] = *from
+	}
+	if to != nil {
+		cond["$lte"] = *to
+	}
+	if len(cond) > 0 {
+		filter[field] = cond
+	}
+}
+
+// ticketSnippets returns up to three highlighted snippets of the title and
+// chat messages of a ticket that match the search terms.
+func ticketSnippets(ticket *model.Ticket, terms []string) []string {
+	const maxSnippets, radius = 3, 40
+	if len(terms) == 0 {
+		return nil
+	}
+
+	texts := []string{ticket.Title}
+	for _, msg := range ticket.Chat {
+		if !msg.Internal && !msg.IsDeleted() {
+			texts = append(texts, msg.Message)
+		}
+	}
+
+	var snippets []string
+	for _, text := range texts {
+		if snippet := util.Snippet(text, terms, radius); snippet != "" {
+			snippets = append(snippets, snippet)
+			if len(snippets) == maxSnippets {
+				break
+			}
+		}
+	}
+	return snippets
+}
+
+func (r *TicketRepository) SetTicketStatus(ctx context.Context, id string, actorID int64, statusId int64) (*dto.TicketResponse, *errx.APIError) {
+	return r.setTicketField(ctx, id, actorID, "ticketStatusId", statusId, func(t *model.Ticket) *int64 { return &t.TicketStatusID })
+}
+
+// SetTicketAssignee sets the agent handling a ticket. An assigneeID of 0 unassigns it.
+func (r *TicketRepository) SetTicketAssignee(ctx context.Context, id string, actorID int64, assigneeID int64) (*dto.TicketResponse, *errx.APIError) {
+	return r.setTicketField(ctx, id, actorID, "assigneeId", assigneeID, func(t *model.Ticket) *int64 { return &t.AssigneeID })
+}
+
+// SetTicketPriority overrides the priority resolved when the ticket was created.
+func (r *TicketRepository) SetTicketPriority(ctx context.Context, id string, actorID int64, priority int64) (*dto.TicketResponse, *errx.APIError) {
+	return r.setTicketField(ctx, id, actorID, "priority", priority, func(t *model.Ticket) *int64 { return &t.Priority })
+}
+
+// setTicketField sets a single int64 field of a ticket and records the change in its history.
+// fieldOf points at the same field on the model so the previous value can be reported.
+func (r *TicketRepository) setTicketField(
+	ctx context.Context,
+	id string,
+	actorID int64,
+	field string,
+	value int64,
+	fieldOf func(*model.Ticket) *int64,
+) (*dto.TicketResponse, *errx.APIError) {
 
 	// Validate UUID
 	uid, err := uuid.Parse(id)
-
 	if err != nil {
 		return nil, errx.Respond(errx.ErrBadRequest, err)
 	}
@@ -263,7 +764,150 @@ func (r *TicketRepository) SetTicketStatus(ctx context.Context, id string, statu
 
 	update := bson.D{
 		{Key: "$set", Value: bson.M{
-			"ticketStatusId": statusId,
+			field: value,
+		}},
+		{Key: "$currentDate", Value: bson.M{
+			"updatedAt": true,
+		}},
+	}
+
+	// Options: return the document before the update to know the old value
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.Before).SetProjection(bson.M{"searchText": 0})
+
+	var ticket model.Ticket
+	err = r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&ticket)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errx.Respond(errx.ErrTicketNotFound, err)
+		}
+		return nil, errx.Respond(errx.ErrInternalServerError, err)
+	}
+
+	oldValue := *fieldOf(&ticket)
+	*fieldOf(&ticket) = value
+	ticket.UpdatedAt = time.Now()
+
+	r.history.RecordFieldChange(ctx, ticket.ID, actorID, field, oldValue, value)
+
+	return dto.ToTicketResponse(&ticket), nil
+}
+
+// AddTicketTags adds tags to a ticket, keeping the ones it already has.
+func (r *TicketRepository) AddTicketTags(ctx context.Context, id string, actorID int64, tagIDs []int64) (*dto.TicketResponse, *errx.APIError) {
+	return r.updateTicketTags(ctx, id, actorID, "$addToSet", bson.M{"tags": bson.M{"$each": tagIDs}}, func(tags []int64) []int64 {
+		for _, tagID := range tagIDs {
+			if !slices.Contains(tags, tagID) {
+				tags = append(tags, tagID)
+			}
+		}
+		return tags
+	})
+}
+
+// RemoveTicketTags removes tags from a ticket. Tags the ticket does not have are ignored.
+func (r *TicketRepository) RemoveTicketTags(ctx context.Context, id string, actorID int64, tagIDs []int64) (*dto.TicketResponse, *errx.APIError) {
+	return r.updateTicketTags(ctx, id, actorID, "$pullAll", bson.M{"tags": tagIDs}, func(tags []int64) []int64 {
+		return slices.DeleteFunc(tags, func(tagID int64) bool { return slices.Contains(tagIDs, tagID) })
+	})
+}
+
+// updateTicketTags applies a tags update to a ticket and records the change in its history.
+// apply computes the same change on the previous tag list so the new list can be reported.
+func (r *TicketRepository) updateTicketTags(
+	ctx context.Context,
+	id string,
+	actorID int64,
+	op string,
+	value bson.M,
+	apply func([]int64) []int64,
+) (*dto.TicketResponse, *errx.APIError) {
+
+	// Validate UUID
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errx.Respond(errx.ErrBadRequest, err)
+	}
+
+	update := bson.D{
+		{Key: op, Value: value},
+		{Key: "$currentDate", Value: bson.M{
+			"updatedAt": true,
+		}},
+	}
+
+	// Options: return the document before the update to know the old tags
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.Before).SetProjection(bson.M{"searchText": 0})
+
+	var ticket model.Ticket
+	err = r.collection.FindOneAndUpdate(ctx, bson.M{"_id": uid.String()}, update, opts).Decode(&ticket)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errx.Respond(errx.ErrTicketNotFound, err)
+		}
+		return nil, errx.Respond(errx.ErrInternalServerError, err)
+	}
+
+	oldTags := ticket.Tags
+	ticket.Tags = apply(slices.Clone(oldTags))
+	ticket.UpdatedAt = time.Now()
+
+	if event, changed := tagsChangeEvent(ticket.ID, actorID, oldTags, ticket.Tags); changed {
+		r.history.Record(ctx, event)
+	}
+
+	return dto.ToTicketResponse(&ticket), nil
+}
+
+// GetTagUsageCounts returns how many tickets matching query carry each tag, most used first.
+// Only TagID and Count of the results are set.
+func (r *TicketRepository) GetTagUsageCounts(ctx context.Context, query dto.TicketQueryParams) ([]dto.TagUsageDTO, *errx.APIError) {
+	if err := query.ValidateRun(); err != nil {
+		return nil, errx.Respond(errx.ErrInvalidTicketFilter, err)
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: ticketFilter(query)}},
+		{{Key: "$unwind", Value: "$tags"}},
+		{{Key: "$group", Value: bson.M{"_id": "$tags", "count": bson.M{"$sum": 1}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, errx.Respond(errx.ErrInternalServerError, err)
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		TagID int64 `bson:"_id"`
+		Count int64 `bson:"count"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, errx.Respond(errx.ErrInternalServerError, err)
+	}
+
+	usage := make([]dto.TagUsageDTO, len(rows))
+	for i, row := range rows {
+		usage[i] = dto.TagUsageDTO{TagID: row.TagID, Count: row.Count}
+	}
+	return usage, nil
+}
+
+// ChangeTicketStatus moves a ticket from fromStatusID to toStatusID. The update only
+// applies if the ticket is still in fromStatusID, so concurrent changes are rejected.
+func (r *TicketRepository) ChangeTicketStatus(ctx context.Context, id string, actorID int64, fromStatusID int64, toStatusID int64) (*dto.TicketResponse, *errx.APIError) {
+
+	// Validate UUID
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errx.Respond(errx.ErrBadRequest, err)
+	}
+
+	filter := bson.M{"_id": uid.String(), "ticketStatusId": fromStatusID}
+
+	update := bson.D{
+		{Key: "$set", Value: bson.M{
+			"ticketStatusId": toStatusID,
 		}},
 		{Key: "$currentDate", Value: bson.M{
 			"updatedAt": true,
@@ -271,16 +915,210 @@ func (r *TicketRepository) SetTicketStatus(ctx context.Context, id string, statu
 	}
 
 	// Options: return the updated document
-	opts := options.FindOneAndUpdate().SetReturnDocument(options.After).SetProjection(bson.M{"chat": 0})
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After).SetProjection(bson.M{"searchText": 0})
 
-	var model model.Ticket
-	err = r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&model)
+	var ticket model.Ticket
+	err = r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&ticket)
 	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errx.Respond(errx.ErrInvalidStatusTransition, errors.New("ticket status was changed by another request"))
+		}
 		return nil, errx.Respond(errx.ErrInternalServerError, err)
 	}
 
-	// Convert to DTO
-	ticketDTO := dto.ToTicketResponse(&model)
+	r.history.RecordFieldChange(ctx, ticket.ID, actorID, "ticketStatusId", fromStatusID, toStatusID)
+
+	return dto.ToTicketResponse(&ticket), nil
+}
+
+// SyncSLAWithStatus updates the SLA clock of a ticket after it moved to a status of the given kind.
+// Returns the updated SLA, or nil if the ticket has none.
+func (r *TicketRepository) SyncSLAWithStatus(ctx context.Context, id string, statusKind string) (*model.TicketSLA, *errx.APIError) {
+
+	// Validate UUID
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errx.Respond(errx.ErrBadRequest, err)
+	}
+
+	now := time.Now()
+	return updateTicketSLA(ctx, r.collection, uid.String(), func(sla *model.TicketSLA) {
+		sla.ApplyStatusKind(statusKind, now)
+	})
+}
+
+// GetTicketsByIDs returns the tickets with the given IDs, without their chat, keyed by ID.
+// Unknown IDs are missing from the result.
+func (r *TicketRepository) GetTicketsByIDs(ctx context.Context, ids []string) (map[string]*model.Ticket, *errx.APIError) {
+	opts := options.Find().SetProjection(bson.M{"searchText": 0})
+	cursor, err := r.collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}}, opts)
+	if err != nil {
+		return nil, errx.Respond(errx.ErrInternalServerError, err)
+	}
+	defer cursor.Close(ctx)
+
+	var tickets []model.Ticket
+	if err := cursor.All(ctx, &tickets); err != nil {
+		return nil, errx.Respond(errx.ErrInternalServerError, err)
+	}
+
+	byID := make(map[string]*model.Ticket, len(tickets))
+	for i := range tickets {
+		byID[tickets[i].ID] = &tickets[i]
+	}
+	return byID, nil
+}
+
+// TicketFieldChange is one ticket of BulkSetTicketField
+type TicketFieldChange struct {
+	TicketID string
+	OldValue int64            // value the ticket must still have for the change to apply
+	OldSLA   *model.TicketSLA // SLA the new SLA was computed from
+	SLA      *model.TicketSLA // SLA to store along with the change, nil leaves it untouched
+}
 
-	return ticketDTO, nil
+// BulkSetTicketField sets field to value on many tickets with a single bulk write and records
+// the changes in their history. A change only applies if the ticket still has its OldValue,
+// and the SLA paths it changes their values of OldSLA.
+// Returns the error of every ticket that was not changed.
+func (r *TicketRepository) BulkSetTicketField(ctx context.Context, actorID int64, field string, value int64, changes []TicketFieldChange) map[string]*errx.APIError {
+	failed := make(map[string]*errx.APIError)
+	if len(changes) == 0 {
+		return failed
+	}
+
+	models := make([]mongo.WriteModel, len(changes))
+	for i, change := range changes {
+		// tickets created before a field existed do not have it, which reads as zero
+		var oldValue any = change.OldValue
+		if change.OldValue == 0 {
+			oldValue = bson.M{"$in": bson.A{0, nil}}
+		}
+		filter := bson.M{"_id": change.TicketID, field: oldValue}
+		set := bson.M{field: value}
+		if change.SLA != nil {
+			slaSet, slaMatch := slaChanges(change.OldSLA, change.SLA)
+			maps.Copy(set, slaSet)
+			maps.Copy(filter, slaMatch)
+		}
+		models[i] = mongo.NewUpdateOneModel().
+			SetFilter(filter).
+			SetUpdate(bson.D{
+				{Key: "$set", Value: set},
+				{Key: "$currentDate", Value: bson.M{"updatedAt": true}},
+			})
+	}
+
+	_, err := r.collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		var bulkErr mongo.BulkWriteException
+		if !errors.As(err, &bulkErr) {
+			for _, change := range changes {
+				failed[change.TicketID] = errx.Respond(errx.ErrInternalServerError, err)
+			}
+			return failed
+		}
+		for _, writeErr := range bulkErr.WriteErrors {
+			failed[changes[writeErr.Index].TicketID] = errx.Respond(errx.ErrInternalServerError, writeErr)
+		}
+	}
+
+	// Tickets that do not have the new value were changed by another request in between
+	ids := make([]string, 0, len(changes))
+	for _, change := range changes {
+		if failed[change.TicketID] == nil {
+			ids = append(ids, change.TicketID)
+		}
+	}
+	cursor, err := r.collection.Find(ctx,
+		bson.M{"_id": bson.M{"$in": ids}, field: bson.M{"$ne": value}},
+		options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		for _, id := range ids {
+			failed[id] = errx.Respond(errx.ErrInternalServerError, err)
+		}
+		return failed
+	}
+	var conflicts []struct {
+		ID string `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &conflicts); err != nil {
+		for _, id := range ids {
+			failed[id] = errx.Respond(errx.ErrInternalServerError, err)
+		}
+		return failed
+	}
+	for _, conflict := range conflicts {
+		failed[conflict.ID] = errx.Respond(errx.ErrTicketConcurrentUpdate, errors.New("ticket was changed by another request"))
+	}
+
+	var events []model.TicketEvent
+	for _, change := range changes {
+		if failed[change.TicketID] == nil && change.OldValue != value {
+			events = append(events, model.TicketEvent{
+				TicketID: change.TicketID,
+				ActorID:  actorID,
+				Action:   model.TicketEventUpdated,
+				Field:    field,
+				OldValue: change.OldValue,
+				NewValue: value,
+			})
+		}
+	}
+	r.history.Record(ctx, events...)
+
+	return failed
+}
+
+// BulkAddTicketTags adds tags to many tickets with a single update and records the
+// new tag list of every ticket in its history.
+func (r *TicketRepository) BulkAddTicketTags(ctx context.Context, actorID int64, tickets []*model.Ticket, tagIDs []int64) *errx.APIError {
+	if len(tickets) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(tickets))
+	for i, ticket := range tickets {
+		ids[i] = ticket.ID
+	}
+
+	update := bson.D{
+		{Key: "$addToSet", Value: bson.M{"tags": bson.M{"$each": tagIDs}}},
+		{Key: "$currentDate", Value: bson.M{"updatedAt": true}},
+	}
+	if _, err := r.collection.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": ids}}, update); err != nil {
+		return errx.Respond(errx.ErrInternalServerError, err)
+	}
+
+	var events []model.TicketEvent
+	for _, ticket := range tickets {
+		tags := slices.Clone(ticket.Tags)
+		for _, tagID := range tagIDs {
+			if !slices.Contains(tags, tagID) {
+				tags = append(tags, tagID)
+			}
+		}
+		if event, changed := tagsChangeEvent(ticket.ID, actorID, ticket.Tags, tags); changed {
+			events = append(events, event)
+		}
+	}
+	r.history.Record(ctx, events...)
+
+	return nil
+}
+
+// tagsChangeEvent returns the history event of a tag list change, if the list changed.
+// Slices are not comparable, so tag changes cannot go through RecordFieldChange.
+func tagsChangeEvent(ticketID string, actorID int64, oldTags, newTags []int64) (model.TicketEvent, bool) {
+	if slices.Equal(oldTags, newTags) {
+		return model.TicketEvent{}, false
+	}
+	return model.TicketEvent{
+		TicketID: ticketID,
+		ActorID:  actorID,
+		Action:   model.TicketEventUpdated,
+		Field:    "tags",
+		OldValue: oldTags,
+		NewValue: newTags,
+	}, true
 }
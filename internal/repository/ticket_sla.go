@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"ticket-api/internal/errx"
+	"ticket-api/internal/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// slaUpdateAttempts bounds how often updateTicketSLA reads the SLA again after another
+// request changed it in between
+const slaUpdateAttempts = 3
+
+// slaChanges returns the sla.* paths that differ between old and updated, and the old values
+// of those paths. Matching the old values in the update filter keeps a concurrent change of
+// the same paths from being overwritten, while changes of other paths are left alone.
+func slaChanges(old, updated *model.TicketSLA) (set bson.M, match bson.M) {
+	set, match = bson.M{}, bson.M{}
+	changeTime := func(path string, from, to time.Time) {
+		if !from.Equal(to) {
+			set["sla."+path], match["sla."+path] = to, from
+		}
+	}
+	changeTimePtr := func(path string, from, to *time.Time) {
+		switch {
+		case from == nil && to == nil:
+		case from == nil || to == nil || !from.Equal(*to):
+			set["sla."+path], match["sla."+path] = to, from
+		}
+	}
+
+	changeTime("firstResponseDueAt", old.FirstResponseDueAt, updated.FirstResponseDueAt)
+	changeTime("resolveDueAt", old.ResolveDueAt, updated.ResolveDueAt)
+	changeTimePtr("firstRespondedAt", old.FirstRespondedAt, updated.FirstRespondedAt)
+	changeTimePtr("resolvedAt", old.ResolvedAt, updated.ResolvedAt)
+	changeTimePtr("pausedAt", old.PausedAt, updated.PausedAt)
+	if old.Breached != updated.Breached {
+		set["sla.breached"], match["sla.breached"] = updated.Breached, old.Breached
+	}
+	return set, match
+}
+
+// updateTicketSLA applies change to the SLA of a ticket and stores only the paths it changed.
+// If another request changed one of them in between, the SLA is read and changed again.
+// Returns the updated SLA, or nil if the ticket has none.
+func updateTicketSLA(ctx context.Context, collection *mongo.Collection, ticketID string, change func(*model.TicketSLA)) (*model.TicketSLA, *errx.APIError) {
+	opts := options.FindOne().SetProjection(bson.M{"sla": 1})
+
+	for range slaUpdateAttempts {
+		var ticket model.Ticket
+		if err := collection.FindOne(ctx, bson.M{"_id": ticketID}, opts).Decode(&ticket); err != nil {
+			if err == mongo.ErrNoDocuments {
+				return nil, errx.Respond(errx.ErrTicketNotFound, err)
+			}
+			return nil, errx.Respond(errx.ErrInternalServerError, err)
+		}
+		if ticket.SLA == nil {
+			return nil, nil
+		}
+
+		old := *ticket.SLA
+		change(ticket.SLA)
+		set, match := slaChanges(&old, ticket.SLA)
+		if len(set) == 0 {
+			return ticket.SLA, nil
+		}
+
+		match["_id"] = ticketID
+		result, err := collection.UpdateOne(ctx, match, bson.M{"$set": set})
+		if err != nil {
+			return nil, errx.Respond(errx.ErrInternalServerError, err)
+		}
+		if result.MatchedCount == 1 {
+			return ticket.SLA, nil
+		}
+	}
+
+	return nil, errx.Respond(errx.ErrTicketConcurrentUpdate, fmt.Errorf("SLA of ticket %s kept changing", ticketID))
+}
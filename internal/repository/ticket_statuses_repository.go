@@ -4,34 +4,43 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"slices"
 	"ticket-api/internal/config"
+	"ticket-api/internal/db/roles_relations"
 	"ticket-api/internal/db/ticket_statuses"
 	"ticket-api/internal/errx"
+	"ticket-api/internal/model"
 	"ticket-api/internal/services/cache"
 	"time"
 )
 
 type TicketStatusesRepository struct {
-	queries *ticket_statuses.Queries
-	cache   *cache.CacheService
+	queries      *ticket_statuses.Queries
+	rolesQueries *roles_relations.Queries
+	cache        *cache.CacheService
 }
 
 // private cache keys
 const (
-	_ticketStatusesAllKey = "ticket_status_all"
-	_ticketStatusCloseKey = "ticket_status_close"
-	_ticketStatusOpenKey  = "ticket_status_open"
+	_ticketStatusesAllKey    = "ticket_status_all"
+	_ticketStatusKindKeyBase = "ticket_status_kind_"
 )
 
-func NewTicketStatusesRepository(queries *ticket_statuses.Queries, cache *cache.CacheService) *TicketStatusesRepository {
+func NewTicketStatusesRepository(queries *ticket_statuses.Queries, rolesQueries *roles_relations.Queries, cache *cache.CacheService) *TicketStatusesRepository {
 	return &TicketStatusesRepository{
-		queries: queries,
-		cache:   cache,
+		queries:      queries,
+		rolesQueries: rolesQueries,
+		cache:        cache,
 	}
 }
 
 // Add a new ticket status and invalidate caches
 func (repo *TicketStatusesRepository) AddTicketStatus(ctx context.Context, param ticket_statuses.AddTicketStatusParams) *errx.APIError {
+	if !slices.Contains(model.TicketStatusKinds, param.Kind) {
+		return errx.Respond(errx.ErrInvalidInput, fmt.Errorf("unknown ticket status kind %q", param.Kind))
+	}
+
 	err := repo.queries.AddTicketStatus(ctx, param)
 	if err != nil {
 		return errx.Respond(errx.ErrInternalServerError, err)
@@ -39,8 +48,9 @@ func (repo *TicketStatusesRepository) AddTicketStatus(ctx context.Context, param
 
 	// invalidate cache
 	_ = repo.cache.Delete(ctx, _ticketStatusesAllKey)
-	_ = repo.cache.Delete(ctx, _ticketStatusOpenKey)
-	_ = repo.cache.Delete(ctx, _ticketStatusCloseKey)
+	for _, kind := range model.TicketStatusKinds {
+		_ = repo.cache.Delete(ctx, _ticketStatusKindKeyBase+kind)
+	}
 	return nil
 }
 
@@ -69,7 +79,6 @@ func (repo *TicketStatusesRepository) GetAllActiveTicketStatuses(ctx context.Con
 	// set caches
 	ttl := time.Duration(config.Get().Cache.TicketStatusTTL) * time.Minute
 	_ = repo.cache.Set(ctx, _ticketStatusesAllKey, statuses, ttl)
-	repo.cacheCloseOpen(ctx, statuses)
 
 	return statuses, nil
 }
@@ -90,19 +99,20 @@ func (repo *TicketStatusesRepository) GetActiveTicketStatusByID(ctx context.Cont
 	return nil, errx.Respond(errx.ErrTicketStatusNotFound, nil)
 }
 
-// Get the "close" ticket status
-func (repo *TicketStatusesRepository) GetCloseStatus(ctx context.Context) (*ticket_statuses.TicketStatus, *errx.APIError) {
-	return repo.getCachedStatus(ctx, _ticketStatusCloseKey, 0)
+// Get the status new tickets start in
+func (repo *TicketStatusesRepository) GetInitialStatus(ctx context.Context) (*ticket_statuses.TicketStatus, *errx.APIError) {
+	return repo.GetStatusByKind(ctx, model.TicketStatusKindInitial)
 }
 
-// Get the "open" ticket status
-func (repo *TicketStatusesRepository) GetOpenStatus(ctx context.Context) (*ticket_statuses.TicketStatus, *errx.APIError) {
-	return repo.getCachedStatus(ctx, _ticketStatusOpenKey, 1)
+// Get the "close" ticket status
+func (repo *TicketStatusesRepository) GetCloseStatus(ctx context.Context) (*ticket_statuses.TicketStatus, *errx.APIError) {
+	return repo.GetStatusByKind(ctx, model.TicketStatusKindClosed)
 }
 
-// --- internal helper to reduce repetition ---
-func (repo *TicketStatusesRepository) getCachedStatus(ctx context.Context, key string, index int) (*ticket_statuses.TicketStatus, *errx.APIError) {
+// GetStatusByKind returns the first active status of the given kind
+func (repo *TicketStatusesRepository) GetStatusByKind(ctx context.Context, kind string) (*ticket_statuses.TicketStatus, *errx.APIError) {
 	var status ticket_statuses.TicketStatus
+	key := _ticketStatusKindKeyBase + kind
 
 	ok, err := repo.cache.Get(ctx, key, &status)
 	if err != nil {
@@ -115,14 +125,14 @@ func (repo *TicketStatusesRepository) getCachedStatus(ctx context.Context, key s
 	// fallback to DB
 	statuses, apiErr := repo.GetAllActiveTicketStatuses(ctx)
 	if apiErr != nil {
-		return nil, errx.Respond(errx.ErrInternalServerError, err)
+		return nil, apiErr
 	}
 
-	if len(statuses) <= index {
-		return nil, errx.Respond(errx.ErrInternalServerError, errors.New("statuses list too short"))
+	idx := slices.IndexFunc(statuses, func(s ticket_statuses.TicketStatus) bool { return s.Kind == kind })
+	if idx < 0 {
+		return nil, errx.Respond(errx.ErrTicketStatusNotFound, fmt.Errorf("no active ticket status of kind %q", kind))
 	}
-
-	status = statuses[index]
+	status = statuses[idx]
 
 	// store in cache
 	ttl := time.Duration(config.Get().Cache.TicketStatusTTL) * time.Minute
@@ -131,11 +141,37 @@ func (repo *TicketStatusesRepository) getCachedStatus(ctx context.Context, key s
 	return &status, nil
 }
 
-// --- helper to cache "close" and "open" separately ---
-func (repo *TicketStatusesRepository) cacheCloseOpen(ctx context.Context, statuses []ticket_statuses.TicketStatus) {
-	if len(statuses) >= 2 {
-		ttl := time.Duration(config.Get().Cache.TicketStatusTTL) * time.Minute
-		_ = repo.cache.Set(ctx, _ticketStatusCloseKey, statuses[0], ttl)
-		_ = repo.cache.Set(ctx, _ticketStatusOpenKey, statuses[1], ttl)
+// ValidateTransition checks that a ticket may move from one status to another
+// and that one of roleIDs is allowed to do it. A transition without role
+// relations can be performed by everyone: this is intended, so transitions
+// requesters need (closing their ticket, replying to a pending one) work
+// without a role of their own. Staff-only transitions must list the staff
+// roles, and callers still check that the user may change the ticket.
+func (repo *TicketStatusesRepository) ValidateTransition(ctx context.Context, fromStatusID int64, toStatusID int64, roleIDs []int64) *errx.APIError {
+	transition, err := repo.queries.GetActiveTicketStatusTransition(ctx, ticket_statuses.GetActiveTicketStatusTransitionParams{
+		FromStatusID: fromStatusID,
+		ToStatusID:   toStatusID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errx.Respond(errx.ErrInvalidStatusTransition, fmt.Errorf("no transition from status %d to %d", fromStatusID, toStatusID))
+		}
+		return errx.Respond(errx.ErrInternalServerError, err)
+	}
+
+	allowedRoleIDs, err := repo.rolesQueries.GetTicketStatusTransitionRoleIDs(ctx, transition.ID)
+	if err != nil {
+		return errx.Respond(errx.ErrInternalServerError, err)
 	}
+	if len(allowedRoleIDs) == 0 {
+		return nil
+	}
+
+	for _, roleID := range roleIDs {
+		if slices.Contains(allowedRoleIDs, roleID) {
+			return nil
+		}
+	}
+
+	return errx.Respond(errx.ErrStatusTransitionForbidden, fmt.Errorf("roles %v may not perform transition %d", roleIDs, transition.ID))
 }
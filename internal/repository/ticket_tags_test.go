@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"slices"
+	"testing"
+	"ticket-api/internal/model"
+)
+
+func TestTagsChangeEvent(t *testing.T) {
+	event, changed := tagsChangeEvent("t1", 7, []int64{1, 2}, []int64{1, 2, 3})
+	if !changed {
+		t.Fatal("expected a change of tags to be reported")
+	}
+	if event.Action != model.TicketEventUpdated || event.Field != "tags" || event.ActorID != 7 || event.TicketID != "t1" {
+		t.Fatalf("unexpected event %+v", event)
+	}
+	if !slices.Equal(event.OldValue.([]int64), []int64{1, 2}) || !slices.Equal(event.NewValue.([]int64), []int64{1, 2, 3}) {
+		t.Fatalf("unexpected tag lists %v -> %v", event.OldValue, event.NewValue)
+	}
+
+	if _, changed := tagsChangeEvent("t1", 7, []int64{4}, []int64{4}); changed {
+		t.Fatal("unchanged tags must not be recorded")
+	}
+}
+
+func TestRecordTagsChangeWithoutHistoryCollection(t *testing.T) {
+	event, _ := tagsChangeEvent("t1", 7, nil, []int64{1})
+	// the history of a disabled MongoDB is a no-op and must not panic on slice values
+	(&TicketHistoryRepository{}).Record(t.Context(), event)
+}
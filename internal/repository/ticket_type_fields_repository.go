@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"ticket-api/internal/config"
+	"ticket-api/internal/db/ticket_type_fields"
+	"ticket-api/internal/errx"
+	"ticket-api/internal/model"
+	"ticket-api/internal/services/cache"
+	"time"
+)
+
+// private cache keys
+const _ticketTypeFieldsKeyBase = "ticket_type_fields_"
+
+type TicketTypeFieldsRepository struct {
+	queries *ticket_type_fields.Queries
+	cache   *cache.CacheService
+}
+
+func NewTicketTypeFieldsRepository(queries *ticket_type_fields.Queries, cache *cache.CacheService) *TicketTypeFieldsRepository {
+	return &TicketTypeFieldsRepository{
+		queries: queries,
+		cache:   cache,
+	}
+}
+
+// AddTicketTypeField adds a custom field definition to a ticket type and invalidates its cache
+func (repo *TicketTypeFieldsRepository) AddTicketTypeField(ctx context.Context, param ticket_type_fields.AddTicketTypeFieldParams) (int64, *errx.APIError) {
+	fieldID, err := repo.queries.AddTicketTypeField(ctx, param)
+	if err != nil {
+		return -1, errx.Respond(errx.ErrInternalServerError, err)
+	}
+
+	_ = repo.cache.Delete(ctx, _ticketTypeFieldsKeyBase+strconv.FormatInt(param.TicketTypeID, 10))
+	return fieldID, nil
+}
+
+// DeleteTicketTypeField removes a custom field definition. Values already stored on tickets are kept.
+func (repo *TicketTypeFieldsRepository) DeleteTicketTypeField(ctx context.Context, id int64) *errx.APIError {
+	field, err := repo.queries.GetTicketTypeFieldByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errx.Respond(errx.ErrTicketTypeFieldNotFound, err)
+		}
+		return errx.Respond(errx.ErrInternalServerError, err)
+	}
+
+	if _, err := repo.queries.DeleteTicketTypeField(ctx, id); err != nil {
+		return errx.Respond(errx.ErrInternalServerError, err)
+	}
+
+	_ = repo.cache.Delete(ctx, _ticketTypeFieldsKeyBase+strconv.FormatInt(field.TicketTypeID, 10))
+	return nil
+}
+
+// GetTicketTypeFields returns the active custom fields of a ticket type in display order, with cache
+func (repo *TicketTypeFieldsRepository) GetTicketTypeFields(ctx context.Context, ticketTypeID int64) ([]ticket_type_fields.TicketTypeField, *errx.APIError) {
+	var fields []ticket_type_fields.TicketTypeField
+	key := _ticketTypeFieldsKeyBase + strconv.FormatInt(ticketTypeID, 10)
+
+	ok, err := repo.cache.Get(ctx, key, &fields)
+	if err != nil {
+		return nil, errx.Respond(errx.ErrInternalServerError, err)
+	}
+	if ok {
+		return fields, nil
+	}
+
+	fields, err = repo.queries.GetActiveTicketTypeFields(ctx, ticketTypeID)
+	if err != nil {
+		return nil, errx.Respond(errx.ErrInternalServerError, err)
+	}
+
+	_ = repo.cache.Set(ctx, key, fields, time.Duration(config.Get().Cache.TicketTypeTTL)*time.Minute)
+	return fields, nil
+}
+
+// ValidateCustomFields checks the custom field values of a new ticket against the fields
+// of its type and returns them in their stored form
+func (repo *TicketTypeFieldsRepository) ValidateCustomFields(ctx context.Context, ticketTypeID int64, values map[string]any) (map[string]any, *errx.APIError) {
+	fields, apiErr := repo.GetTicketTypeFields(ctx, ticketTypeID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	normalized, err := model.ValidateCustomFields(fields, values)
+	if err != nil {
+		return nil, errx.Respond(errx.ErrInvalidCustomField, fmt.Errorf("ticket type %d: %w", ticketTypeID, err))
+	}
+	return normalized, nil
+}
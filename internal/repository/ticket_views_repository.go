@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"ticket-api/internal/db/ticket_views"
+	"ticket-api/internal/dto"
+	"ticket-api/internal/errx"
+)
+
+type TicketViewsRepository struct {
+	queries *ticket_views.Queries
+}
+
+func NewTicketViewsRepository(queries *ticket_views.Queries) *TicketViewsRepository {
+	return &TicketViewsRepository{
+		queries: queries,
+	}
+}
+
+// encodeTicketViewQuery validates a view query with the rules of GetTickets and encodes it.
+// Paging is not part of a view, it is given when the view is run.
+func encodeTicketViewQuery(query dto.TicketQueryParams) (string, *errx.APIError) {
+	if err := query.Validate(); err != nil {
+		return "", errx.Respond(errx.ErrInvalidTicketFilter, err)
+	}
+
+	query.Page = 0
+	query.Cursor = nil
+
+	data, err := json.Marshal(query)
+	if err != nil {
+		return "", errx.Respond(errx.ErrInternalServerError, err)
+	}
+	return string(data), nil
+}
+
+func boolToInt64(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// AddTicketView saves a new view owned by userID and returns its ID
+func (repo *TicketViewsRepository) AddTicketView(ctx context.Context, userID int64, departmentID int64, view dto.TicketViewRequest) (int64, *errx.APIError) {
+	query, apiErr := encodeTicketViewQuery(view.Query)
+	if apiErr != nil {
+		return -1, apiErr
+	}
+
+	id, err := repo.queries.AddTicketView(ctx, ticket_views.AddTicketViewParams{
+		UserID:       userID,
+		DepartmentID: departmentID,
+		Title:        view.Title,
+		Query:        query,
+		Shared:       boolToInt64(view.Shared),
+	})
+	if err != nil {
+		return -1, errx.Respond(errx.ErrInternalServerError, err)
+	}
+	return id, nil
+}
+
+// UpdateTicketView replaces a view. Only the owner can update it.
+func (repo *TicketViewsRepository) UpdateTicketView(ctx context.Context, id int64, userID int64, departmentID int64, view dto.TicketViewRequest) *errx.APIError {
+	query, apiErr := encodeTicketViewQuery(view.Query)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	rows, err := repo.queries.UpdateTicketView(ctx, ticket_views.UpdateTicketViewParams{
+		Title:        view.Title,
+		Query:        query,
+		Shared:       boolToInt64(view.Shared),
+		DepartmentID: departmentID,
+		ID:           id,
+		UserID:       userID,
+	})
+	if err != nil {
+		return errx.Respond(errx.ErrInternalServerError, err)
+	}
+	if rows == 0 {
+		return errx.Respond(errx.ErrTicketViewNotFound, errors.New("view not found or not owned by user"))
+	}
+	return nil
+}
+
+// DeleteTicketView soft deletes a view. Only the owner can delete it.
+func (repo *TicketViewsRepository) DeleteTicketView(ctx context.Context, id int64, userID int64) *errx.APIError {
+	rows, err := repo.queries.DeleteTicketView(ctx, ticket_views.DeleteTicketViewParams{ID: id, UserID: userID})
+	if err != nil {
+		return errx.Respond(errx.ErrInternalServerError, err)
+	}
+	if rows == 0 {
+		return errx.Respond(errx.ErrTicketViewNotFound, errors.New("view not found or not owned by user"))
+	}
+	return nil
+}
+
+func (repo *TicketViewsRepository) GetTicketViewByID(ctx context.Context, id int64) (*dto.TicketViewDTO, *errx.APIError) {
+	view, err := repo.queries.GetTicketViewByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errx.Respond(errx.ErrTicketViewNotFound, err)
+		}
+		return nil, errx.Respond(errx.ErrInternalServerError, err)
+	}
+
+	viewDTO, err := dto.ToTicketViewDTO(&view)
+	if err != nil {
+		return nil, errx.Respond(errx.ErrInternalServerError, err)
+	}
+	return viewDTO, nil
+}
+
+// GetVisibleTicketViews returns the views of a user and the views shared with their department
+func (repo *TicketViewsRepository) GetVisibleTicketViews(ctx context.Context, userID int64, departmentID int64) ([]dto.TicketViewDTO, *errx.APIError) {
+	views, err := repo.queries.GetVisibleTicketViews(ctx, ticket_views.GetVisibleTicketViewsParams{
+		UserID:       userID,
+		DepartmentID: departmentID,
+	})
+	if err != nil {
+		return nil, errx.Respond(errx.ErrInternalServerError, err)
+	}
+
+	viewsDTO := make([]dto.TicketViewDTO, 0, len(views))
+	for i := range views {
+		viewDTO, err := dto.ToTicketViewDTO(&views[i])
+		if err != nil {
+			return nil, errx.Respond(errx.ErrInternalServerError, err)
+		}
+		viewsDTO = append(viewsDTO, *viewDTO)
+	}
+	return viewsDTO, nil
+}
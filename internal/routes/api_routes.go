@@ -10,22 +10,28 @@ type _Prefix struct {
 }
 
 type _APIPrefixes struct {
-	Versions   _Prefix
-	Tickets    _Prefix
-	Auth       _Prefix
-	Captcha    _Prefix
-	User       _Prefix
-	Department _Prefix
-	Files      _Prefix
+	Versions        _Prefix
+	Tickets         _Prefix
+	TicketViews     _Prefix
+	Tags            _Prefix
+	CannedResponses _Prefix
+	Auth            _Prefix
+	Captcha         _Prefix
+	User            _Prefix
+	Department      _Prefix
+	Files           _Prefix
 }
 
 var _APIRoutesPrefixes = _APIPrefixes{
-	Tickets:    _Prefix{prefix: "tickets/"},
-	Auth:       _Prefix{prefix: "auth/"},
-	Captcha:    _Prefix{prefix: "captcha/"},
-	User:       _Prefix{prefix: "users/"},
-	Department: _Prefix{prefix: "departments/"},
-	Files:      _Prefix{prefix: "files/"},
+	Tickets:         _Prefix{prefix: "tickets/"},
+	TicketViews:     _Prefix{prefix: "tickets/views/"},
+	Tags:            _Prefix{prefix: "tags/"},
+	CannedResponses: _Prefix{prefix: "cannedResponses/"},
+	Auth:            _Prefix{prefix: "auth/"},
+	Captcha:         _Prefix{prefix: "captcha/"},
+	User:            _Prefix{prefix: "users/"},
+	Department:      _Prefix{prefix: "departments/"},
+	Files:           _Prefix{prefix: "files/"},
 }
 
 type HTTPMethod string
@@ -53,9 +59,50 @@ type tickets struct {
 	GetTicketByID              _APIRoute
 	GetTicketByTrackCode       _APIRoute
 	CreateChat                 _APIRoute
+	CreateInternalNote         _APIRoute
+	EditChat                   _APIRoute
+	DeleteChat                 _APIRoute
+	Stream                     _APIRoute
+	LiveChat                   _APIRoute
+	MarkTicketRead             _APIRoute
+	GetTicketMessages          _APIRoute
 	GetTicketsList             _APIRoute
 	GetAllActiveTicketTypes    _APIRoute
 	GetAllActiveTicketStatuses _APIRoute
+	GetTicketTypeFields        _APIRoute
+	AddTicketTypeField         _APIRoute
+	DeleteTicketTypeField      _APIRoute
+	AssignTicket               _APIRoute
+	UnassignTicket             _APIRoute
+	ChangeTicketStatus         _APIRoute
+	SetTicketPriority          _APIRoute
+	AddTicketTags              _APIRoute
+	RemoveTicketTags           _APIRoute
+	BulkUpdate                 _APIRoute
+	GetTicketHistory           _APIRoute
+}
+
+type ticketViews struct {
+	CreateView _APIRoute
+	UpdateView _APIRoute
+	DeleteView _APIRoute
+	GetViews   _APIRoute
+	RunView    _APIRoute
+}
+
+type tags struct {
+	CreateTag        _APIRoute
+	UpdateTag        _APIRoute
+	GetAllActiveTags _APIRoute
+	GetTagUsage      _APIRoute
+}
+
+type cannedResponses struct {
+	CreateCannedResponse   _APIRoute
+	UpdateCannedResponse   _APIRoute
+	DeleteCannedResponse   _APIRoute
+	GetCannedResponses     _APIRoute
+	GetCannedResponseUsage _APIRoute
 }
 
 type departments struct {
@@ -84,15 +131,19 @@ type captcha struct {
 type files struct {
 	UploadTicketFile          _APIRoute
 	GetDownloadLinkTicketFile _APIRoute
+	DownloadTicketFile        _APIRoute
 }
 type _APIEndpoints struct {
-	Versions    versions
-	Tickets     tickets
-	Files       files
-	Auth        auth
-	Captcha     captcha
-	Users       users
-	Departments departments
+	Versions        versions
+	Tickets         tickets
+	TicketViews     ticketViews
+	Tags            tags
+	CannedResponses cannedResponses
+	Files           files
+	Auth            auth
+	Captcha         captcha
+	Users           users
+	Departments     departments
 }
 
 var APIRoutes = _APIEndpoints{
@@ -103,10 +154,48 @@ var APIRoutes = _APIEndpoints{
 		CreateTicket:               _APIRoute{Path: mergeStrings(_APIRoutesPrefixes.Tickets.prefix, "CreateTicket/"), method: string(PostMethod), Status: true},
 		GetTicketByID:              _APIRoute{Path: mergeStrings(_APIRoutesPrefixes.Tickets.prefix, "GetTicketByID/"), method: string(GetMethod), Status: true},
 		CreateChat:                 _APIRoute{Path: mergeStrings(_APIRoutesPrefixes.Tickets.prefix, ":id/CreateChat/"), method: string(PostMethod), Status: true},
+		CreateInternalNote:         _APIRoute{Path: mergeStrings(_APIRoutesPrefixes.Tickets.prefix, ":id/CreateInternalNote/"), method: string(PostMethod), Status: true},
+		EditChat:                   _APIRoute{Path: mergeStrings(_APIRoutesPrefixes.Tickets.prefix, ":id/EditChat/"), method: string(PostMethod), Status: true},
+		DeleteChat:                 _APIRoute{Path: mergeStrings(_APIRoutesPrefixes.Tickets.prefix, ":id/DeleteChat/"), method: string(PostMethod), Status: true},
+		Stream:                     _APIRoute{Path: mergeStrings(_APIRoutesPrefixes.Tickets.prefix, ":id/Stream/"), method: string(GetMethod), Status: true},
+		LiveChat:                   _APIRoute{Path: mergeStrings(_APIRoutesPrefixes.Tickets.prefix, ":id/LiveChat/"), method: string(GetMethod), Status: true},
+		MarkTicketRead:             _APIRoute{Path: mergeStrings(_APIRoutesPrefixes.Tickets.prefix, "MarkTicketRead/"), method: string(PostMethod), Status: true},
+		GetTicketMessages:          _APIRoute{Path: mergeStrings(_APIRoutesPrefixes.Tickets.prefix, ":id/Messages/"), method: string(GetMethod), Status: true},
 		GetTicketByTrackCode:       _APIRoute{Path: mergeStrings(_APIRoutesPrefixes.Tickets.prefix, "GetTicketByTrackCode/"), method: string(PostMethod), Status: true},
 		GetTicketsList:             _APIRoute{Path: mergeStrings(_APIRoutesPrefixes.Tickets.prefix, "GetTicketsList/"), method: string(PostMethod), Status: true},
 		GetAllActiveTicketTypes:    _APIRoute{Path: mergeStrings(_APIRoutesPrefixes.Tickets.prefix, "GetAllActiveTicketTypes/"), method: string(GetMethod), Status: true},
 		GetAllActiveTicketStatuses: _APIRoute{Path: mergeStrings(_APIRoutesPrefixes.Tickets.prefix, "GetAllActiveTicketStatuses/"), method: string(GetMethod), Status: true},
+		GetTicketTypeFields:        _APIRoute{Path: mergeStrings(_APIRoutesPrefixes.Tickets.prefix, "GetTicketTypeFields/"), method: string(GetMethod), Status: true},
+		AddTicketTypeField:         _APIRoute{Path: mergeStrings(_APIRoutesPrefixes.Tickets.prefix, "AddTicketTypeField/"), method: string(PostMethod), Status: true},
+		DeleteTicketTypeField:      _APIRoute{Path: mergeStrings(_APIRoutesPrefixes.Tickets.prefix, "DeleteTicketTypeField/"), method: string(PostMethod), Status: true},
+		AssignTicket:               _APIRoute{Path: mergeStrings(_APIRoutesPrefixes.Tickets.prefix, "AssignTicket/"), method: string(PostMethod), Status: true},
+		UnassignTicket:             _APIRoute{Path: mergeStrings(_APIRoutesPrefixes.Tickets.prefix, "UnassignTicket/"), method: string(PostMethod), Status: true},
+		ChangeTicketStatus:         _APIRoute{Path: mergeStrings(_APIRoutesPrefixes.Tickets.prefix, "ChangeTicketStatus/"), method: string(PostMethod), Status: true},
+		SetTicketPriority:          _APIRoute{Path: mergeStrings(_APIRoutesPrefixes.Tickets.prefix, "SetTicketPriority/"), method: string(PostMethod), Status: true},
+		AddTicketTags:              _APIRoute{Path: mergeStrings(_APIRoutesPrefixes.Tickets.prefix, "AddTicketTags/"), method: string(PostMethod), Status: true},
+		RemoveTicketTags:           _APIRoute{Path: mergeStrings(_APIRoutesPrefixes.Tickets.prefix, "RemoveTicketTags/"), method: string(PostMethod), Status: true},
+		BulkUpdate:                 _APIRoute{Path: mergeStrings(_APIRoutesPrefixes.Tickets.prefix, "BulkUpdate/"), method: string(PostMethod), Status: true},
+		GetTicketHistory:           _APIRoute{Path: mergeStrings(_APIRoutesPrefixes.Tickets.prefix, ":id/History/"), method: string(GetMethod), Status: true},
+	},
+	TicketViews: ticketViews{
+		CreateView: _APIRoute{Path: mergeStrings(_APIRoutesPrefixes.TicketViews.prefix, "CreateView/"), method: string(PostMethod), Status: true},
+		UpdateView: _APIRoute{Path: mergeStrings(_APIRoutesPrefixes.TicketViews.prefix, "UpdateView/"), method: string(PostMethod), Status: true},
+		DeleteView: _APIRoute{Path: mergeStrings(_APIRoutesPrefixes.TicketViews.prefix, "DeleteView/"), method: string(PostMethod), Status: true},
+		GetViews:   _APIRoute{Path: mergeStrings(_APIRoutesPrefixes.TicketViews.prefix, "GetViews/"), method: string(GetMethod), Status: true},
+		RunView:    _APIRoute{Path: mergeStrings(_APIRoutesPrefixes.TicketViews.prefix, "RunView/"), method: string(PostMethod), Status: true},
+	},
+	Tags: tags{
+		CreateTag:        _APIRoute{Path: mergeStrings(_APIRoutesPrefixes.Tags.prefix, "CreateTag/"), method: string(PostMethod), Status: true},
+		UpdateTag:        _APIRoute{Path: mergeStrings(_APIRoutesPrefixes.Tags.prefix, "UpdateTag/"), method: string(PostMethod), Status: true},
+		GetAllActiveTags: _APIRoute{Path: mergeStrings(_APIRoutesPrefixes.Tags.prefix, "GetAllActiveTags/"), method: string(GetMethod), Status: true},
+		GetTagUsage:      _APIRoute{Path: mergeStrings(_APIRoutesPrefixes.Tags.prefix, "GetTagUsage/"), method: string(PostMethod), Status: true},
+	},
+	CannedResponses: cannedResponses{
+		CreateCannedResponse:   _APIRoute{Path: mergeStrings(_APIRoutesPrefixes.CannedResponses.prefix, "CreateCannedResponse/"), method: string(PostMethod), Status: true},
+		UpdateCannedResponse:   _APIRoute{Path: mergeStrings(_APIRoutesPrefixes.CannedResponses.prefix, "UpdateCannedResponse/"), method: string(PostMethod), Status: true},
+		DeleteCannedResponse:   _APIRoute{Path: mergeStrings(_APIRoutesPrefixes.CannedResponses.prefix, "DeleteCannedResponse/"), method: string(PostMethod), Status: true},
+		GetCannedResponses:     _APIRoute{Path: mergeStrings(_APIRoutesPrefixes.CannedResponses.prefix, "GetCannedResponses/"), method: string(GetMethod), Status: true},
+		GetCannedResponseUsage: _APIRoute{Path: mergeStrings(_APIRoutesPrefixes.CannedResponses.prefix, "GetCannedResponseUsage/"), method: string(PostMethod), Status: true},
 	},
 	Auth: auth{
 		LoginWithNoAuth:         _APIRoute{Path: mergeStrings(_APIRoutesPrefixes.Auth.prefix, "LoginWithNoAuth/"), method: string(GetMethod), Status: true},
@@ -130,6 +219,7 @@ var APIRoutes = _APIEndpoints{
 	Files: files{
 		UploadTicketFile:          _APIRoute{Path: mergeStrings(_APIRoutesPrefixes.Files.prefix, "UploadTicketFile/"), method: string(PostMethod), Status: true},
 		GetDownloadLinkTicketFile: _APIRoute{Path: mergeStrings(_APIRoutesPrefixes.Files.prefix, "GetDownloadLinkTicketFile/:objectName"), method: string(PostMethod), Status: true},
+		DownloadTicketFile:        _APIRoute{Path: mergeStrings(_APIRoutesPrefixes.Files.prefix, "Download/*objectName"), method: string(GetMethod), Status: true},
 	},
 }
 
@@ -147,9 +237,41 @@ func IsRouteEnabled(path, method string) bool {
 		APIRoutes.Tickets.GetTicketByID,
 		APIRoutes.Tickets.GetTicketByTrackCode,
 		APIRoutes.Tickets.CreateChat,
+		APIRoutes.Tickets.CreateInternalNote,
+		APIRoutes.Tickets.EditChat,
+		APIRoutes.Tickets.DeleteChat,
+		APIRoutes.Tickets.Stream,
+		APIRoutes.Tickets.LiveChat,
+		APIRoutes.Tickets.MarkTicketRead,
+		APIRoutes.Tickets.GetTicketMessages,
 		APIRoutes.Tickets.GetTicketsList,
 		APIRoutes.Tickets.GetAllActiveTicketTypes,
 		APIRoutes.Tickets.GetAllActiveTicketStatuses,
+		APIRoutes.Tickets.GetTicketTypeFields,
+		APIRoutes.Tickets.AddTicketTypeField,
+		APIRoutes.Tickets.DeleteTicketTypeField,
+		APIRoutes.Tickets.AssignTicket,
+		APIRoutes.Tickets.UnassignTicket,
+		APIRoutes.Tickets.ChangeTicketStatus,
+		APIRoutes.Tickets.SetTicketPriority,
+		APIRoutes.Tickets.AddTicketTags,
+		APIRoutes.Tickets.RemoveTicketTags,
+		APIRoutes.Tickets.BulkUpdate,
+		APIRoutes.Tickets.GetTicketHistory,
+		APIRoutes.TicketViews.CreateView,
+		APIRoutes.TicketViews.UpdateView,
+		APIRoutes.TicketViews.DeleteView,
+		APIRoutes.TicketViews.GetViews,
+		APIRoutes.TicketViews.RunView,
+		APIRoutes.Tags.CreateTag,
+		APIRoutes.Tags.UpdateTag,
+		APIRoutes.Tags.GetAllActiveTags,
+		APIRoutes.Tags.GetTagUsage,
+		APIRoutes.CannedResponses.CreateCannedResponse,
+		APIRoutes.CannedResponses.UpdateCannedResponse,
+		APIRoutes.CannedResponses.DeleteCannedResponse,
+		APIRoutes.CannedResponses.GetCannedResponses,
+		APIRoutes.CannedResponses.GetCannedResponseUsage,
 		APIRoutes.Auth.LoginWithNoAuth,
 		APIRoutes.Auth.SignUp,
 		APIRoutes.Auth.Login,
@@ -162,6 +284,7 @@ func IsRouteEnabled(path, method string) bool {
 		APIRoutes.Users.GetUserByID,
 		APIRoutes.Users.GetUsersByIDs,
 		APIRoutes.Files.GetDownloadLinkTicketFile,
+		APIRoutes.Files.DownloadTicketFile,
 		APIRoutes.Files.UploadTicketFile,
 	}
 	for _, r := range allRoutes {
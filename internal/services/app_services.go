@@ -3,7 +3,9 @@ package services
 import (
 	"ticket-api/internal/services/cache"
 	"ticket-api/internal/services/captcha"
+	"ticket-api/internal/services/janitor"
 	"ticket-api/internal/services/storage"
+	"ticket-api/internal/services/stream"
 	"ticket-api/internal/services/token"
 
 	"github.com/minio/minio-go/v7"
@@ -15,13 +17,23 @@ type AppServices struct {
 	Token       *token.TokenService
 	Cache       *cache.CacheService
 	FileStorage *storage.StorageService
+	Stream      *stream.StreamService
+	Janitor     *janitor.JanitorService
 }
 
-func NewAppService(redis *redis.Client, minio *minio.Client) *AppServices {
+func NewAppService(redis *redis.Client, minio *minio.Client) (*AppServices, error) {
+	cacheService := cache.NewCacheService(redis)
+	fileStorage, err := storage.NewStorageService(minio, cacheService)
+	if err != nil {
+		return nil, err
+	}
+
 	return &AppServices{
 		Captcha:     captcha.NewCaptchaService(),
 		Token:       token.NewTokenService(),
-		Cache:       cache.NewCacheService(redis),
-		FileStorage: storage.NewStorageService(minio),
-	}
+		Cache:       cacheService,
+		FileStorage: fileStorage,
+		Stream:      stream.NewStreamService(redis),
+		Janitor:     janitor.NewJanitorService(redis, fileStorage),
+	}, nil
 }
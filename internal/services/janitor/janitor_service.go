@@ -0,0 +1,176 @@
+// Package janitor deletes temp uploads that were never attached to a ticket. Every API
+// instance runs it, a leader lease in Redis makes sure only one of them cleans up.
+package janitor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"ticket-api/internal/config"
+	"ticket-api/internal/services/storage"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	leaderKey = "janitor:temp_cleanup:leader"
+	// leaderTTL is how long a crashed leader blocks the other instances
+	leaderTTL = time.Minute
+)
+
+// acquireScript renews the lease if this instance holds it, or takes it if it is free
+var acquireScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return 1
+end
+return 0
+`)
+
+// releaseScript frees the lease only if this instance still holds it
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+type JanitorService struct {
+	redis      *redis.Client
+	storage    *storage.StorageService
+	instanceID string
+
+	mu           sync.Mutex
+	cancelLeader context.CancelFunc // cancels the cleanup of this instance when the lease is lost
+	leaderCtx    context.Context    // set while this instance holds the lease
+	wg           sync.WaitGroup
+}
+
+// NewJanitorService creates a janitor with a random instance ID for the leader lease
+func NewJanitorService(redis *redis.Client, storage *storage.StorageService) *JanitorService {
+	return &JanitorService{
+		redis:      redis,
+		storage:    storage,
+		instanceID: uuid.NewString(),
+	}
+}
+
+// Start checks the cleanup config and starts holding the leader lease and cleaning up temp
+// uploads every configured interval while this instance is the leader, until ctx is done.
+// Wait blocks until both have stopped and the lease was released.
+func (j *JanitorService) Start(ctx context.Context) error {
+	cfg := config.Get().Storage.TempCleanup
+	if cfg.IntervalMinutes <= 0 {
+		return fmt.Errorf("storage.temp_cleanup.interval_minutes must be positive, got %d", cfg.IntervalMinutes)
+	}
+	if cfg.MaxAgeMinutes <= 0 {
+		return fmt.Errorf("storage.temp_cleanup.max_age_minutes must be positive, got %d", cfg.MaxAgeMinutes)
+	}
+	interval := time.Duration(cfg.IntervalMinutes) * time.Minute
+	maxAge := time.Duration(cfg.MaxAgeMinutes) * time.Minute
+
+	j.wg.Add(2)
+	go j.holdLease(ctx)
+	go j.cleanupLoop(ctx, interval, maxAge)
+	return nil
+}
+
+// Wait blocks until a started janitor has stopped
+func (j *JanitorService) Wait() {
+	j.wg.Wait()
+}
+
+// holdLease takes and renews the leader lease on its own, so a long cleanup never lets it
+// expire. A lost lease cancels the running cleanup. The lease is handed over on shutdown.
+func (j *JanitorService) holdLease(ctx context.Context) {
+	defer j.wg.Done()
+	defer j.release()
+
+	renew := time.NewTicker(leaderTTL / 3)
+	defer renew.Stop()
+
+	for {
+		j.setLeader(ctx, j.acquire(ctx))
+		select {
+		case <-ctx.Done():
+			j.setLeader(ctx, false)
+			return
+		case <-renew.C:
+		}
+	}
+}
+
+// setLeader creates the context of the cleanups when the lease is taken and cancels it
+// when the lease is lost
+func (j *JanitorService) setLeader(ctx context.Context, held bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	switch {
+	case held && j.leaderCtx == nil:
+		j.leaderCtx, j.cancelLeader = context.WithCancel(ctx)
+	case !held && j.leaderCtx != nil:
+		j.cancelLeader()
+		j.leaderCtx, j.cancelLeader = nil, nil
+	}
+}
+
+// leader returns the context of the cleanups, nil if this instance is not the leader
+func (j *JanitorService) leader() context.Context {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.leaderCtx
+}
+
+// cleanupLoop deletes old temp uploads every interval while this instance is the leader
+func (j *JanitorService) cleanupLoop(ctx context.Context, interval, maxAge time.Duration) {
+	defer j.wg.Done()
+
+	cleanup := time.NewTicker(interval)
+	defer cleanup.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-cleanup.C:
+		}
+
+		leaderCtx := j.leader()
+		if leaderCtx == nil {
+			continue
+		}
+		report, err := j.storage.CleanupTempFiles(leaderCtx, maxAge)
+		if err != nil {
+			if report != nil {
+				log.Printf("⚠️ temp upload cleanup stopped after deleting %d temp uploads, reclaiming %d bytes: %v", report.Files, report.Bytes, err)
+			} else {
+				log.Printf("⚠️ temp upload cleanup failed: %v", err)
+			}
+			continue
+		}
+		log.Printf("🧹 Deleted %d temp uploads, reclaimed %d bytes", report.Files, report.Bytes)
+	}
+}
+
+// acquire takes or renews the leader lease and reports whether this instance holds it
+func (j *JanitorService) acquire(ctx context.Context) bool {
+	held, err := acquireScript.Run(ctx, j.redis, []string{leaderKey}, j.instanceID, leaderTTL.Milliseconds()).Int()
+	if err != nil {
+		log.Printf("⚠️ failed to acquire janitor leader lease: %v", err)
+		return false
+	}
+	return held == 1
+}
+
+// release hands the lease over to another instance right away on shutdown
+func (j *JanitorService) release() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = releaseScript.Run(ctx, j.redis, []string{leaderKey}, j.instanceID).Err()
+}
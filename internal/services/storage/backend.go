@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"ticket-api/internal/errx"
+	"time"
+)
+
+// Storage drivers selectable in the storage section of the config
+const (
+	DriverMinio = "minio"
+	DriverLocal = "local"
+)
+
+// ObjectInfo describes a stored file
+type ObjectInfo struct {
+	Name         string
+	Size         int64
+	ContentType  string
+	LastModified time.Time
+}
+
+// Backend keeps the files of tickets. Object names are slash separated paths such as
+// "tickets/temp/<uuid>.pdf". Every method reports a missing object with errx.ErrFileNotFound.
+type Backend interface {
+	// Upload stores the content of reader under objectName, replacing any existing object
+	Upload(ctx context.Context, objectName string, reader io.Reader, size int64, contentType string) *errx.APIError
+	// Stat describes an object without reading it
+	Stat(ctx context.Context, objectName string) (*ObjectInfo, *errx.APIError)
+	// Open reads an object, the caller closes the reader
+	Open(ctx context.Context, objectName string) (io.ReadCloser, *ObjectInfo, *errx.APIError)
+	// Copy duplicates an object
+	Copy(ctx context.Context, srcName, dstName string) *errx.APIError
+	// Move renames an object
+	Move(ctx context.Context, srcName, dstName string) *errx.APIError
+	// Delete removes an object
+	Delete(ctx context.Context, objectName string) *errx.APIError
+	// PresignedURL returns a download link of an object valid for expires
+	PresignedURL(ctx context.Context, objectName string, expires time.Duration) (string, *errx.APIError)
+	// List describes every object whose name starts with prefix
+	List(ctx context.Context, prefix string) ([]ObjectInfo, *errx.APIError)
+}
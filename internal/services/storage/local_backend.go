@@ -0,0 +1,298 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"ticket-api/internal/errx"
+)
+
+// LocalBackend keeps files in a directory of the API server. The content type of each file
+// is kept in a hidden sidecar file next to it. Download links point at the download route of
+// the API and carry an HMAC signature of the object name and expiry.
+type LocalBackend struct {
+	root        string
+	downloadURL string
+	secret      []byte
+}
+
+// NewLocalBackend creates the root directory if needed. The secret signs download links
+// and must be at least 32 bytes.
+func NewLocalBackend(root, downloadURL string, secret []byte) (*LocalBackend, error) {
+	if root == "" {
+		return nil, errors.New("local storage root is not set")
+	}
+	if downloadURL == "" {
+		return nil, errors.New("local storage download url is not set")
+	}
+	if len(secret) < 32 {
+		return nil, errors.New("local storage signing secret missing or too short")
+	}
+
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(absRoot, 0o750); err != nil {
+		return nil, err
+	}
+
+	return &LocalBackend{
+		root:        absRoot,
+		downloadURL: strings.TrimSuffix(downloadURL, "/") + "/",
+		secret:      secret,
+	}, nil
+}
+
+// objectPath returns the file of an object, refusing names that escape the root
+func (b *LocalBackend) objectPath(objectName string) (string, *errx.APIError) {
+	clean := path.Clean("/" + objectName)
+	if clean == "/" || clean != "/"+objectName {
+		return "", errx.Respond(errx.ErrBadRequest, fmt.Errorf("invalid object name %q", objectName))
+	}
+	return filepath.Join(b.root, filepath.FromSlash(clean)), nil
+}
+
+// contentTypeSuffix names the sidecar file keeping the content type of an object
+const contentTypeSuffix = ".content-type"
+
+// contentTypePath returns the sidecar file of the content type of an object file
+func contentTypePath(file string) string {
+	return filepath.Join(filepath.Dir(file), "."+filepath.Base(file)+contentTypeSuffix)
+}
+
+// isContentTypeFile reports whether a file is the sidecar of another object
+func isContentTypeFile(file string) bool {
+	base := filepath.Base(file)
+	return strings.HasPrefix(base, ".") && strings.HasSuffix(base, contentTypeSuffix)
+}
+
+// localError maps a file system error to an API error
+func localError(err error) *errx.APIError {
+	if errors.Is(err, fs.ErrNotExist) {
+		return errx.Respond(errx.ErrFileNotFound, err)
+	}
+	return errx.Respond(errx.ErrInternalServerError, err)
+}
+
+// Upload writes to a temporary file first so readers never see a partial object. The
+// content type is stored before the object appears; without one it is derived from the
+// extension when the object is read.
+func (b *LocalBackend) Upload(_ context.Context, objectName string, reader io.Reader, size int64, contentType string) *errx.APIError {
+	dst, apiErr := b.objectPath(objectName)
+	if apiErr != nil {
+		return apiErr
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o750); err != nil {
+		return localError(err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".upload-*")
+	if err != nil {
+		return localError(err)
+	}
+	defer os.Remove(tmp.Name())
+
+	written, err := io.Copy(tmp, reader)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return localError(err)
+	}
+	if size >= 0 && written != size {
+		return errx.Respond(errx.ErrBadRequest, fmt.Errorf("uploaded %d bytes, expected %d", written, size))
+	}
+
+	if contentType != "" {
+		if err := os.WriteFile(contentTypePath(dst), []byte(contentType), 0o640); err != nil {
+			return localError(err)
+		}
+	} else if err := os.Remove(contentTypePath(dst)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return localError(err)
+	}
+
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		return localError(err)
+	}
+	return nil
+}
+
+func (b *LocalBackend) Stat(_ context.Context, objectName string) (*ObjectInfo, *errx.APIError) {
+	file, apiErr := b.objectPath(objectName)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, localError(err)
+	}
+	if info.IsDir() || isContentTypeFile(file) {
+		return nil, errx.Respond(errx.ErrFileNotFound, fmt.Errorf("%s is not an object", objectName))
+	}
+	return localObjectInfo(objectName, file, info), nil
+}
+
+func (b *LocalBackend) Open(ctx context.Context, objectName string) (io.ReadCloser, *ObjectInfo, *errx.APIError) {
+	info, apiErr := b.Stat(ctx, objectName)
+	if apiErr != nil {
+		return nil, nil, apiErr
+	}
+
+	// objectPath cannot fail after Stat succeeded
+	file, _ := b.objectPath(objectName)
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, nil, localError(err)
+	}
+	return f, info, nil
+}
+
+func (b *LocalBackend) Copy(ctx context.Context, srcName, dstName string) *errx.APIError {
+	src, info, apiErr := b.Open(ctx, srcName)
+	if apiErr != nil {
+		return apiErr
+	}
+	defer src.Close()
+
+	return b.Upload(ctx, dstName, src, info.Size, info.ContentType)
+}
+
+func (b *LocalBackend) Move(_ context.Context, srcName, dstName string) *errx.APIError {
+	src, apiErr := b.objectPath(srcName)
+	if apiErr != nil {
+		return apiErr
+	}
+	dst, apiErr := b.objectPath(dstName)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o750); err != nil {
+		return localError(err)
+	}
+	if err := os.Rename(src, dst); err != nil {
+		return localError(err)
+	}
+	if err := os.Rename(contentTypePath(src), contentTypePath(dst)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return localError(err)
+	}
+	return nil
+}
+
+func (b *LocalBackend) Delete(_ context.Context, objectName string) *errx.APIError {
+	file, apiErr := b.objectPath(objectName)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	if err := os.Remove(file); err != nil {
+		return localError(err)
+	}
+	if err := os.Remove(contentTypePath(file)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return localError(err)
+	}
+	return nil
+}
+
+// PresignedURL returns a link to the download route of the API, signed until now+expires
+func (b *LocalBackend) PresignedURL(ctx context.Context, objectName string, expires time.Duration) (string, *errx.APIError) {
+	if _, apiErr := b.Stat(ctx, objectName); apiErr != nil {
+		return "", apiErr
+	}
+
+	expiresAt := strconv.FormatInt(time.Now().Add(expires).Unix(), 10)
+	return fmt.Sprintf("%s%s?expires=%s&signature=%s", b.downloadURL, objectName, expiresAt, b.sign(objectName, expiresAt)), nil
+}
+
+// VerifySignature checks a download link issued by PresignedURL
+func (b *LocalBackend) VerifySignature(objectName, expires, signature string) *errx.APIError {
+	if !hmac.Equal([]byte(signature), []byte(b.sign(objectName, expires))) {
+		return errx.Respond(errx.ErrUnauthorized, errors.New("invalid download link signature"))
+	}
+
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return errx.Respond(errx.ErrBadRequest, err)
+	}
+	if time.Now().Unix() > expiresAt {
+		return errx.Respond(errx.ErrLinkExpired, fmt.Errorf("link of %s expired", objectName))
+	}
+	return nil
+}
+
+func (b *LocalBackend) sign(objectName, expires string) string {
+	mac := hmac.New(sha256.New, b.secret)
+	mac.Write([]byte(objectName + "\n" + expires))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// List walks the directory of prefix. Unfinished uploads are listed too, as dot files;
+// content type sidecars are not, they go with their object.
+func (b *LocalBackend) List(_ context.Context, prefix string) ([]ObjectInfo, *errx.APIError) {
+	dir, apiErr := b.objectPath(strings.TrimSuffix(prefix, "/"))
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	objects := []ObjectInfo{}
+	err := filepath.WalkDir(dir, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || isContentTypeFile(file) {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(b.root, file)
+		if err != nil {
+			return err
+		}
+		objects = append(objects, *localObjectInfo(filepath.ToSlash(rel), file, info))
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, localError(err)
+	}
+	return objects, nil
+}
+
+// localObjectInfo describes an object file, with the content type of its sidecar, or of its
+// extension if it has none
+func localObjectInfo(objectName string, file string, info fs.FileInfo) *ObjectInfo {
+	contentType := ""
+	if stored, err := os.ReadFile(contentTypePath(file)); err == nil {
+		contentType = string(stored)
+	}
+	if contentType == "" {
+		contentType = mime.TypeByExtension(path.Ext(objectName))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	return &ObjectInfo{
+		Name:         objectName,
+		Size:         info.Size(),
+		ContentType:  contentType,
+		LastModified: info.ModTime(),
+	}
+}
@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/url"
+	"time"
+
+	"ticket-api/internal/errx"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// MinioBackend keeps files in a MinIO bucket
+type MinioBackend struct {
+	client *minio.Client
+	bucket string
+}
+
+// NewMinioBackend ensures the bucket exists, creating it if needed
+func NewMinioBackend(client *minio.Client, bucket string) (*MinioBackend, error) {
+	if client == nil {
+		return nil, errors.New("minio storage driver selected but MinIO is not connected")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	exists, err := client.BucketExists(ctx, bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		if err := client.MakeBucket(ctx, bucket, minio.MakeBucketOptions{}); err != nil {
+			return nil, err
+		}
+		log.Printf("✅ Bucket %s created successfully", bucket)
+	} else {
+		log.Printf("✅ Bucket %s already exists", bucket)
+	}
+
+	return &MinioBackend{client: client, bucket: bucket}, nil
+}
+
+// minioError maps a MinIO error to an API error
+func minioError(err error) *errx.APIError {
+	if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return errx.Respond(errx.ErrFileNotFound, err)
+	}
+	return errx.Respond(errx.ErrServiceUnavailable, err)
+}
+
+func (b *MinioBackend) Upload(ctx context.Context, objectName string, reader io.Reader, size int64, contentType string) *errx.APIError {
+	_, err := b.client.PutObject(ctx, b.bucket, objectName, reader, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	if err != nil {
+		return errx.Respond(errx.ErrServiceUnavailable, err)
+	}
+	return nil
+}
+
+func (b *MinioBackend) Stat(ctx context.Context, objectName string) (*ObjectInfo, *errx.APIError) {
+	info, err := b.client.StatObject(ctx, b.bucket, objectName, minio.StatObjectOptions{})
+	if err != nil {
+		return nil, minioError(err)
+	}
+	return toObjectInfo(info), nil
+}
+
+func (b *MinioBackend) Open(ctx context.Context, objectName string) (io.ReadCloser, *ObjectInfo, *errx.APIError) {
+	obj, err := b.client.GetObject(ctx, b.bucket, objectName, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, nil, minioError(err)
+	}
+
+	// GetObject is lazy, Stat reports a missing object
+	info, err := obj.Stat()
+	if err != nil {
+		obj.Close()
+		return nil, nil, minioError(err)
+	}
+	return obj, toObjectInfo(info), nil
+}
+
+func (b *MinioBackend) Copy(ctx context.Context, srcName, dstName string) *errx.APIError {
+	src := minio.CopySrcOptions{Bucket: b.bucket, Object: srcName}
+	dst := minio.CopyDestOptions{Bucket: b.bucket, Object: dstName}
+
+	if _, err := b.client.CopyObject(ctx, dst, src); err != nil {
+		return minioError(err)
+	}
+	return nil
+}
+
+// Move copies the object and deletes the source. A source that cannot be deleted is left
+// behind and logged, the copy is already in place.
+func (b *MinioBackend) Move(ctx context.Context, srcName, dstName string) *errx.APIError {
+	if apiErr := b.Copy(ctx, srcName, dstName); apiErr != nil {
+		return apiErr
+	}
+
+	if err := b.client.RemoveObject(ctx, b.bucket, srcName, minio.RemoveObjectOptions{}); err != nil {
+		log.Printf("⚠️ failed to delete moved file %s: %v", srcName, err)
+	}
+	return nil
+}
+
+func (b *MinioBackend) Delete(ctx context.Context, objectName string) *errx.APIError {
+	// RemoveObject succeeds for missing objects
+	if _, apiErr := b.Stat(ctx, objectName); apiErr != nil {
+		return apiErr
+	}
+
+	if err := b.client.RemoveObject(ctx, b.bucket, objectName, minio.RemoveObjectOptions{}); err != nil {
+		return minioError(err)
+	}
+	return nil
+}
+
+func (b *MinioBackend) PresignedURL(ctx context.Context, objectName string, expires time.Duration) (string, *errx.APIError) {
+	urlObj, err := b.client.PresignedGetObject(ctx, b.bucket, objectName, expires, url.Values{})
+	if err != nil {
+		return "", minioError(err)
+	}
+	return urlObj.String(), nil
+}
+
+func (b *MinioBackend) List(ctx context.Context, prefix string) ([]ObjectInfo, *errx.APIError) {
+	objects := []ObjectInfo{}
+	for info := range b.client.ListObjects(ctx, b.bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if info.Err != nil {
+			return nil, errx.Respond(errx.ErrServiceUnavailable, info.Err)
+		}
+		objects = append(objects, *toObjectInfo(info))
+	}
+	return objects, nil
+}
+
+func toObjectInfo(info minio.ObjectInfo) *ObjectInfo {
+	return &ObjectInfo{
+		Name:         info.Key,
+		Size:         info.Size,
+		ContentType:  info.ContentType,
+		LastModified: info.LastModified,
+	}
+}
@@ -2,20 +2,24 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
-	"net/url"
 	"ticket-api/internal/config"
+	"ticket-api/internal/env"
 	"ticket-api/internal/errx"
+	"ticket-api/internal/services/cache"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/minio/minio-go/v7"
 )
 
+// StorageService keeps ticket attachments in the backend selected by config
 type StorageService struct {
-	Client *minio.Client
+	backend Backend
+	cache   *cache.CacheService
 }
 
 const (
@@ -23,78 +27,69 @@ const (
 	TicketPath = "tickets/files/"
 )
 
-// NewStorageService creates a new MinIO client and ensures the bucket exists
-func NewStorageService(minioClient *minio.Client) *StorageService {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	bucket := config.Get().Minio.Bucket
-	exists, err := minioClient.BucketExists(ctx, bucket)
-	if err != nil {
-		log.Fatal(err)
+// NewStorageService creates the backend of the configured storage driver. minioClient is
+// only used by the minio driver and may be nil otherwise. The cache keeps the uploaders of
+// temp files, it may be nil for tools that never upload or attach files.
+func NewStorageService(minioClient *minio.Client, cache *cache.CacheService) (*StorageService, error) {
+	cfg := config.Get().Storage
+
+	var backend Backend
+	var err error
+	switch cfg.Driver {
+	case DriverMinio, "":
+		backend, err = NewMinioBackend(minioClient, config.Get().Minio.Bucket)
+	case DriverLocal:
+		secret := []byte(env.GetEnvString("STORAGE_SIGNING_SECRET", ""))
+		backend, err = NewLocalBackend(cfg.Local.Root, cfg.Local.DownloadURL, secret)
+	default:
+		err = fmt.Errorf("unknown storage driver %q", cfg.Driver)
 	}
-
-	if !exists {
-		if err := minioClient.MakeBucket(ctx, bucket, minio.MakeBucketOptions{}); err != nil {
-			log.Fatal(err)
-		}
-		log.Printf("✅ Bucket %s created successfully", bucket)
-	} else {
-		log.Printf("✅ Bucket %s already exists", bucket)
+	if err != nil {
+		return nil, err
 	}
 
-	return &StorageService{Client: minioClient}
+	return &StorageService{backend: backend, cache: cache}, nil
 }
 
-// UploadFileFromReader uploads a file to MinIO from an io.Reader
+// UploadFileFromReader uploads a file from an io.Reader
 func (m *StorageService) UploadFileFromReader(ctx context.Context, objectName string, fileReader io.Reader, fileSize int64, contentType string) (string, *errx.APIError) {
-	bucket := config.Get().Minio.Bucket
-
-	_, err := m.Client.PutObject(ctx, bucket, objectName, fileReader, fileSize, minio.PutObjectOptions{
-		ContentType: contentType,
-	})
-	if err != nil {
-		return "", errx.Respond(errx.ErrServiceUnavailable, err)
+	if apiErr := m.backend.Upload(ctx, objectName, fileReader, fileSize, contentType); apiErr != nil {
+		return "", apiErr
 	}
-
 	return objectName, nil
 }
 
-// UploadTicketFileToTemp uploads a ticket file from an HTTP request to the temp path
-func (m *StorageService) UploadTicketFileToTemp(ctx context.Context, filename string, fileReader io.Reader, fileSize int64, contentType string) (string, *errx.APIError) {
+// UploadTicketFileToTemp uploads a ticket file from an HTTP request to the temp path. Only
+// the uploader may attach it, until the temp upload TTL passes.
+func (m *StorageService) UploadTicketFileToTemp(ctx context.Context, uploader Uploader, filename string, fileReader io.Reader, fileSize int64, contentType string) (string, *errx.APIError) {
+	owner, apiErr := newTempUploadOwner(uploader)
+	if apiErr != nil {
+		return "", apiErr
+	}
+
 	objectName := fmt.Sprintf("%s%s", TmpPath, filename)
-	return m.UploadFileFromReader(ctx, objectName, fileReader, fileSize, contentType)
-}
+	if _, apiErr := m.UploadFileFromReader(ctx, objectName, fileReader, fileSize, contentType); apiErr != nil {
+		return "", apiErr
+	}
 
-// GetFile streams a file from MinIO
-func (m *StorageService) GetFile(ctx context.Context, objectName string) (*minio.Object, *errx.APIError) {
-	bucket := config.Get().Minio.Bucket
-	obj, err := m.Client.GetObject(ctx, bucket, objectName, minio.GetObjectOptions{})
-	if err != nil {
-		errResp := minio.ToErrorResponse(err)
-		if errResp.Code == "NoSuchKey" {
-			return nil, errx.Respond(errx.ErrFileNotFound, err)
+	// a file nobody may attach is useless, leave nothing behind
+	if apiErr := m.recordTempUpload(ctx, filename, owner); apiErr != nil {
+		if delErr := m.backend.Delete(ctx, objectName); delErr != nil {
+			log.Printf("⚠️ failed to delete temp file %s: %v", objectName, delErr)
 		}
-		return nil, errx.Respond(errx.ErrServiceUnavailable, err)
+		return "", apiErr
 	}
-	return obj, nil
+	return objectName, nil
+}
+
+// GetFile streams a file, the caller closes the reader
+func (m *StorageService) GetFile(ctx context.Context, objectName string) (io.ReadCloser, *ObjectInfo, *errx.APIError) {
+	return m.backend.Open(ctx, objectName)
 }
 
 // GetPresignedURL generates a presigned URL for downloading
 func (m *StorageService) GetPresignedURL(ctx context.Context, objectName string, expires time.Duration) (string, *errx.APIError) {
-	reqParams := url.Values{}
-	bucket := config.Get().Minio.Bucket
-
-	urlObj, err := m.Client.PresignedGetObject(ctx, bucket, objectName, expires, reqParams)
-	if err != nil {
-		errResp := minio.ToErrorResponse(err)
-		if errResp.Code == "NoSuchKey" {
-			return "", errx.Respond(errx.ErrFileNotFound, err)
-		}
-		return "", errx.Respond(errx.ErrServiceUnavailable, err)
-	}
-
-	return urlObj.String(), nil
+	return m.backend.PresignedURL(ctx, objectName, expires)
 }
 
 func (m *StorageService) GetPresignedTicketFileURL(ctx context.Context, ticketID string, filename string) (string, *errx.APIError) {
@@ -106,23 +101,76 @@ func (m *StorageService) GetPresignedTicketFileURL(ctx context.Context, ticketID
 	return m.GetPresignedURL(ctx, objectName, 15*time.Minute)
 }
 
-// DeleteFile removes a file from MinIO
+// GetSignedFile opens a file for a download link signed by the local backend. Other
+// backends serve their links themselves, so they never have signed files.
+func (m *StorageService) GetSignedFile(ctx context.Context, objectName, expires, signature string) (io.ReadCloser, *ObjectInfo, *errx.APIError) {
+	local, ok := m.backend.(*LocalBackend)
+	if !ok {
+		return nil, nil, errx.Respond(errx.ErrFileNotFound, errors.New("storage backend does not serve signed links"))
+	}
+
+	if apiErr := local.VerifySignature(objectName, expires, signature); apiErr != nil {
+		return nil, nil, apiErr
+	}
+	return local.Open(ctx, objectName)
+}
+
+// DeleteFile removes a file
 func (m *StorageService) DeleteFile(ctx context.Context, objectName string) *errx.APIError {
-	bucket := config.Get().Minio.Bucket
-	err := m.Client.RemoveObject(ctx, bucket, objectName, minio.RemoveObjectOptions{})
+	return m.backend.Delete(ctx, objectName)
+}
+
+// DeleteTicketFile removes an attachment from the folder of a ticket
+func (m *StorageService) DeleteTicketFile(ctx context.Context, ticketID string, filename string) *errx.APIError {
+	uid, err := uuid.Parse(ticketID)
 	if err != nil {
-		errResp := minio.ToErrorResponse(err)
-		if errResp.Code == "NoSuchKey" {
-			return errx.Respond(errx.ErrFileNotFound, err)
+		return errx.Respond(errx.ErrBadRequest, err)
+	}
+	return m.DeleteFile(ctx, fmt.Sprintf("%s%s/%s", TicketPath, uid, filename))
+}
+
+// CleanupReport sums up a cleanup of temp uploads
+type CleanupReport struct {
+	Files int   // deleted files
+	Bytes int64 // reclaimed bytes
+}
+
+// CleanupTempFiles deletes the temp uploads older than maxAge, which were never attached to a
+// ticket. Files that cannot be deleted are logged and skipped, so concurrent runs are harmless.
+// A cancelled ctx stops the cleanup between deletions, the report then covers the files
+// deleted so far.
+func (m *StorageService) CleanupTempFiles(ctx context.Context, maxAge time.Duration) (*CleanupReport, *errx.APIError) {
+	objects, apiErr := m.backend.List(ctx, TmpPath)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	cutoff := time.Now().Add(-maxAge)
+	report := &CleanupReport{}
+	for _, obj := range objects {
+		if err := ctx.Err(); err != nil {
+			return report, errx.Respond(errx.ErrServiceUnavailable, err)
+		}
+		if !obj.LastModified.Before(cutoff) {
+			continue
 		}
-		return errx.Respond(errx.ErrServiceUnavailable, err)
+
+		if apiErr := m.backend.Delete(ctx, obj.Name); apiErr != nil {
+			if apiErr.Err.Code != errx.ErrFileNotFound {
+				log.Printf("⚠️ failed to delete temp file %s: %v", obj.Name, apiErr)
+			}
+			continue
+		}
+		report.Files++
+		report.Bytes += obj.Size
 	}
-	return nil
+	return report, nil
 }
 
-// MoveTempsFileToTickets moves specific files from temp to ticket folder
+// MoveTempsFileToTickets moves specific files from temp to ticket folder. Every temp file
+// must belong to the uploader, otherwise none is moved.
 // Returns the list of successfully moved object names
-func (m *StorageService) MoveTempsFileToTickets(ctx context.Context, ticketID string, objectNames []string) ([]string, *errx.APIError) {
+func (m *StorageService) MoveTempsFileToTickets(ctx context.Context, ticketID string, uploader Uploader, objectNames []string) ([]string, *errx.APIError) {
 
 	// Validate UUID
 	uid, err := uuid.Parse(ticketID)
@@ -130,50 +178,38 @@ func (m *StorageService) MoveTempsFileToTickets(ctx context.Context, ticketID st
 		return nil, errx.Respond(errx.ErrBadRequest, err)
 	}
 
-	bucket := config.Get().Minio.Bucket
+	for _, name := range objectNames {
+		if apiErr := m.checkTempUpload(ctx, name, uploader); apiErr != nil {
+			return nil, apiErr
+		}
+	}
+
 	successful := []string{}
 
 	for _, name := range objectNames {
 		tmpName := fmt.Sprintf("%s%s", TmpPath, name)
+		destKey := fmt.Sprintf("%s%s/%s", TicketPath, uid, name)
 
 		// Check if object exists in the temporary path
-		_, err := m.Client.StatObject(ctx, bucket, tmpName, minio.StatObjectOptions{})
-		if err != nil {
-			errResp := minio.ToErrorResponse(err)
-
-			if errResp.Code == "NoSuchKey" {
-				// Object not in tmp, check ticket path
-				ticketName := fmt.Sprintf("%s%s/%s", TicketPath, uid, name)
-				_, ticketErr := m.Client.StatObject(ctx, bucket, ticketName, minio.StatObjectOptions{})
-				if ticketErr == nil {
-					successful = append(successful, name)
-					continue
-				}
-
-				// Object not found anywhere
-				return successful, errx.Respond(errx.ErrFileNotFound, err)
+		if _, apiErr := m.backend.Stat(ctx, tmpName); apiErr != nil {
+			if apiErr.Err.Code != errx.ErrFileNotFound {
+				return successful, apiErr
 			}
 
-			// Other internal MinIO error
-			return successful, errx.Respond(errx.ErrInternalServerError, err)
-		}
-
-		destKey := fmt.Sprintf("%s%s/%s", TicketPath, uid, name)
-
-		// Copy object to ticket folder
-		src := minio.CopySrcOptions{Bucket: bucket, Object: tmpName}
-		dst := minio.CopyDestOptions{Bucket: bucket, Object: destKey}
+			// Object not in tmp, check ticket path
+			if _, ticketErr := m.backend.Stat(ctx, destKey); ticketErr == nil {
+				successful = append(successful, name)
+				continue
+			}
 
-		_, err = m.Client.CopyObject(ctx, dst, src)
-		if err != nil {
-			return successful, errx.Respond(errx.ErrInternalServerError, err)
+			// Object not found anywhere
+			return successful, apiErr
 		}
 
-		// Delete the temp object (best-effort)
-		err = m.Client.RemoveObject(ctx, bucket, tmpName, minio.RemoveObjectOptions{})
-		if err != nil {
-			fmt.Printf("⚠️ failed to delete temp file %s: %v\n", name, err)
+		if apiErr := m.backend.Move(ctx, tmpName, destKey); apiErr != nil {
+			return successful, apiErr
 		}
+		_ = m.cache.Delete(ctx, tempUploadPrefix+name)
 
 		successful = append(successful, name)
 	}
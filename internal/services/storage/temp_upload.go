@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"ticket-api/internal/config"
+	"ticket-api/internal/errx"
+	"time"
+)
+
+const tempUploadPrefix = "temp_upload:"
+
+// Uploader identifies the client of a request handling temp uploads: a signed-in user, or
+// an anonymous client by its captcha token and IP. A signed-in client may carry a captcha
+// token too, so files uploaded before signing in still belong to it.
+type Uploader struct {
+	UserID       int64
+	CaptchaToken string
+	IP           string
+}
+
+// tempUploadOwner is recorded for every temp upload until it is attached or expires. The
+// captcha token is only kept as a hash.
+type tempUploadOwner struct {
+	UserID      int64  `json:"userId,omitempty"`
+	CaptchaHash string `json:"captchaHash,omitempty"`
+	IP          string `json:"ip,omitempty"`
+}
+
+func hashCaptchaToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// newTempUploadOwner records the user of the uploader, or its captcha token and IP when
+// it is anonymous
+func newTempUploadOwner(uploader Uploader) (*tempUploadOwner, *errx.APIError) {
+	if uploader.UserID != 0 {
+		return &tempUploadOwner{UserID: uploader.UserID}, nil
+	}
+	if uploader.CaptchaToken == "" {
+		return nil, errx.Respond(errx.ErrUnauthorized, errors.New("anonymous upload without captcha token"))
+	}
+	return &tempUploadOwner{CaptchaHash: hashCaptchaToken(uploader.CaptchaToken), IP: uploader.IP}, nil
+}
+
+// ownedBy reports whether the upload belongs to the uploader. Anonymous uploads need the same
+// captcha token, and the same IP if captcha tokens are bound to it.
+func (o *tempUploadOwner) ownedBy(uploader Uploader) bool {
+	if o.UserID != 0 {
+		return o.UserID == uploader.UserID
+	}
+	if uploader.CaptchaToken == "" || o.CaptchaHash != hashCaptchaToken(uploader.CaptchaToken) {
+		return false
+	}
+	return !config.Get().Captcha.ValidateIP || o.IP == uploader.IP
+}
+
+// recordTempUpload binds a temp upload to its uploader until the configured TTL passes
+func (m *StorageService) recordTempUpload(ctx context.Context, name string, owner *tempUploadOwner) *errx.APIError {
+	ttl := time.Duration(config.Get().Storage.TempUploadTTL) * time.Minute
+	if err := m.cache.Set(ctx, tempUploadPrefix+name, owner, ttl); err != nil {
+		return errx.Respond(errx.ErrInternalServerError, err)
+	}
+	return nil
+}
+
+// checkTempUpload refuses a temp file that belongs to someone else, or whose owner record
+// expired. Files no longer in the temp path are left to the move, which accepts those already
+// attached to the ticket.
+func (m *StorageService) checkTempUpload(ctx context.Context, name string, uploader Uploader) *errx.APIError {
+	var owner tempUploadOwner
+	found, err := m.cache.Get(ctx, tempUploadPrefix+name, &owner)
+	if err != nil {
+		return errx.Respond(errx.ErrInternalServerError, err)
+	}
+
+	if found {
+		if !owner.ownedBy(uploader) {
+			return errx.Respond(errx.ErrTempFileNotOwned, fmt.Errorf("temp file %s belongs to another uploader", name))
+		}
+		return nil
+	}
+
+	_, apiErr := m.backend.Stat(ctx, TmpPath+name)
+	if apiErr == nil {
+		return errx.Respond(errx.ErrTempFileNotOwned, fmt.Errorf("temp file %s expired", name))
+	}
+	if apiErr.Err.Code != errx.ErrFileNotFound {
+		return apiErr
+	}
+	return nil
+}
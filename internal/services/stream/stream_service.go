@@ -0,0 +1,136 @@
+// Package stream fans out live ticket updates to every API instance through Redis pub/sub
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"ticket-api/internal/dto"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	channelPrefix  = "ticket_stream:"
+	presencePrefix = "ticket_presence:"
+)
+
+type StreamService struct {
+	redis *redis.Client
+}
+
+// NewStreamService creates a new stream service
+func NewStreamService(redis *redis.Client) *StreamService {
+	return &StreamService{
+		redis: redis,
+	}
+}
+
+// Publish sends an event to the subscribers of its ticket on every API instance
+func (s *StreamService) Publish(ctx context.Context, event *dto.TicketStreamEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return s.redis.Publish(ctx, channel(event.TicketID), data).Err()
+}
+
+// Subscribe returns the events of a ticket published from now on. The channel is
+// closed and the Redis subscription released when ctx is done.
+func (s *StreamService) Subscribe(ctx context.Context, ticketID string) (<-chan dto.TicketStreamEvent, error) {
+	sub := s.redis.Subscribe(ctx, channel(ticketID))
+
+	// wait for the subscription to be confirmed so no event published afterwards is missed
+	if _, err := sub.Receive(ctx); err != nil {
+		_ = sub.Close()
+		return nil, err
+	}
+
+	events := make(chan dto.TicketStreamEvent, 16)
+	go func() {
+		defer close(events)
+		defer sub.Close()
+
+		messages := sub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				var event dto.TicketStreamEvent
+				if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+					continue
+				}
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// channel returns the pub/sub channel of a ticket. IDs are UUIDs, lowercasing
+// makes publishers and subscribers agree whatever case the client sent.
+func channel(ticketID string) string {
+	return channelPrefix + strings.ToLower(ticketID)
+}
+
+// Join marks a live chat connection of a user as viewing a ticket. The mark expires after
+// ttl, so connections call Join again before then and crashed instances drop out on their own.
+func (s *StreamService) Join(ctx context.Context, ticketID string, userID int64, connID string, ttl time.Duration) error {
+	key := presenceKey(ticketID)
+	pipe := s.redis.TxPipeline()
+	pipe.ZAdd(ctx, key, redis.Z{Score: float64(time.Now().Add(ttl).Unix()), Member: presenceMember(userID, connID)})
+	pipe.Expire(ctx, key, ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Leave removes a live chat connection from the viewers of a ticket
+func (s *StreamService) Leave(ctx context.Context, ticketID string, userID int64, connID string) error {
+	return s.redis.ZRem(ctx, presenceKey(ticketID), presenceMember(userID, connID)).Err()
+}
+
+// Viewers returns the distinct users with a live chat connection to a ticket, sorted by ID
+func (s *StreamService) Viewers(ctx context.Context, ticketID string) ([]int64, error) {
+	key := presenceKey(ticketID)
+	if err := s.redis.ZRemRangeByScore(ctx, key, "-inf", strconv.FormatInt(time.Now().Unix(), 10)).Err(); err != nil {
+		return nil, err
+	}
+	members, err := s.redis.ZRange(ctx, key, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	viewers := make([]int64, 0, len(members))
+	for _, member := range members {
+		userPart, _, _ := strings.Cut(member, ":")
+		userID, parseErr := strconv.ParseInt(userPart, 10, 64)
+		if parseErr != nil {
+			continue
+		}
+		viewers = append(viewers, userID)
+	}
+	slices.Sort(viewers)
+	return slices.Compact(viewers), nil
+}
+
+func presenceKey(ticketID string) string {
+	return presencePrefix + strings.ToLower(ticketID)
+}
+
+// presenceMember identifies one connection, a user may have the ticket open in several tabs
+func presenceMember(userID int64, connID string) string {
+	return fmt.Sprintf("%d:%s", userID, connID)
+}
@@ -0,0 +1,111 @@
+package util
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// searchRuneReplacer maps characters that are typed differently on Arabic and Persian
+// keyboards to a single form. Every mapping is one rune to one rune so positions in the
+// normalized text match positions in the original text.
+var searchRuneReplacer = map[rune]rune{
+	'ي':      'ی', // Arabic yeh
+	'ى':      'ی', // Alef maksura
+	'ك':      'ک', // Arabic kaf
+	'ة':      'ه', // Teh marbuta
+	'\u200c': ' ', // Zero-width non-joiner
+	'\u200d': ' ', // Zero-width joiner
+}
+
+// NormalizeSearchText lowercases s, unifies Arabic and Persian letter variants, turns
+// zero-width joiners into spaces and converts Persian and Arabic digits to ASCII.
+// The result always has the same number of runes as s.
+func NormalizeSearchText(s string) string {
+	return string(normalizeSearchRunes([]rune(s)))
+}
+
+func normalizeSearchRunes(runes []rune) []rune {
+	out := make([]rune, len(runes))
+	for i, r := range runes {
+		switch {
+		case r >= '۰' && r <= '۹':
+			r = '0' + (r - '۰')
+		case r >= '٠' && r <= '٩':
+			r = '0' + (r - '٠')
+		default:
+			if mapped, ok := searchRuneReplacer[r]; ok {
+				r = mapped
+			}
+		}
+		out[i] = unicode.ToLower(r)
+	}
+	return out
+}
+
+// SearchTerms splits a search query into the normalized words to highlight,
+// dropping quotes and negated words.
+func SearchTerms(query string) []string {
+	var terms []string
+	for _, term := range strings.Fields(NormalizeSearchText(query)) {
+		if strings.HasPrefix(term, "-") {
+			continue
+		}
+		term = strings.Trim(term, `"`)
+		if term != "" {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// Snippet returns the part of text around the first match of any term, with every
+// match wrapped in <mark></mark>. radius is the number of runes kept on each side.
+// The text is HTML escaped, so the snippet is safe to render as HTML.
+// Returns an empty string if no term occurs in text.
+func Snippet(text string, terms []string, radius int) string {
+	original := []rune(text)
+	normalized := normalizeSearchRunes(original)
+
+	type span struct{ start, end int }
+	var matches []span
+	for _, term := range terms {
+		needle := []rune(term)
+		for i := 0; i+len(needle) <= len(normalized); i++ {
+			if string(normalized[i:i+len(needle)]) == term {
+				matches = append(matches, span{i, i + len(needle)})
+				i += len(needle) - 1
+			}
+		}
+	}
+	if len(matches) == 0 {
+		return ""
+	}
+
+	sort.Slice(matches, func(i, j int) bool { return matches[i].start < matches[j].start })
+
+	from := max(matches[0].start-radius, 0)
+	to := min(matches[0].end+radius, len(original))
+
+	var b strings.Builder
+	if from > 0 {
+		b.WriteString("…")
+	}
+	pos := from
+	for _, m := range matches {
+		if m.start < pos || m.end > to {
+			continue
+		}
+		b.WriteString(html.EscapeString(string(original[pos:m.start])))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(string(original[m.start:m.end])))
+		b.WriteString("</mark>")
+		pos = m.end
+	}
+	b.WriteString(html.EscapeString(string(original[pos:to])))
+	if to < len(original) {
+		b.WriteString("…")
+	}
+	return b.String()
+}